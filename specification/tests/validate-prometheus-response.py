@@ -151,11 +151,16 @@ class PrometheusResponseValidator:
         # Required labels that should be in Prometheus metrics
         # These are essential for querying and filtering metrics
         required_labels = [
-            'service_name',  # Service identifier (or peer_service for external calls)
+            'peer_service',  # Service called (or the service itself for internal operations)
             'log_type',      # Type of operation (transaction, job.status, job.progress)
             'log_level',     # Log level (info, error, etc.)
         ]
 
+        # The service itself comes from the OTLP resource: Prometheus gets it as
+        # the job label. service_name is an optional data point label that only
+        # some implementations send, so either one identifies the service
+        service_labels = ['job', 'service_name']
+
         # List of camelCase labels that should NOT exist
         camel_case_labels = [
             'serviceName', 'logType', 'logLevel', 'peerService',
@@ -175,13 +180,9 @@ class PrometheusResponseValidator:
                     self.print_warning(f"Series {series_idx}: Invalid metric value: {value[1]}")
 
             # Check for required labels
-            missing_labels = []
-            for label in required_labels:
-                if label not in metric_labels:
-                    # Special case: service_name OR peer_service can satisfy service requirement
-                    if label == 'service_name' and 'peer_service' in metric_labels:
-                        continue  # peer_service is acceptable instead of service_name
-                    missing_labels.append(label)
+            missing_labels = [label for label in required_labels if label not in metric_labels]
+            if not any(label in metric_labels for label in service_labels):
+                missing_labels.append(' or '.join(service_labels))
 
             if missing_labels:
                 self.print_error(f"Series {series_idx}: Missing required labels: {missing_labels}")
@@ -196,8 +197,9 @@ class PrometheusResponseValidator:
                 all_valid = False
 
             # Track stats
-            if 'service_name' in metric_labels:
-                self.stats['unique_services'].add(metric_labels['service_name'])
+            service = metric_labels.get('service_name') or metric_labels.get('job')
+            if service:
+                self.stats['unique_services'].add(service)
             if 'log_type' in metric_labels:
                 log_type = metric_labels['log_type']
                 self.stats['log_types'][log_type] = self.stats['log_types'].get(log_type, 0) + 1
@@ -96,7 +96,7 @@ if ! command -v curl &> /dev/null; then
 fi
 
 # Build PromQL query (same as query-prometheus.sh)
-PROMQL_QUERY="sovdev_operations_total{service_name=\"${SERVICE_NAME}\"}"
+PROMQL_QUERY="sovdev_operations_total{job=\"${SERVICE_NAME}\"}"
 
 # Query Prometheus through Grafana datasource proxy
 if [[ "$JSON_MODE" == false ]]; then
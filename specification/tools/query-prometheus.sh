@@ -142,8 +142,9 @@ if ! kubectl get svc -n monitoring prometheus-server &> /dev/null; then
     exit 1
 fi
 
-# Build PromQL query
-PROMQL_QUERY="${METRIC_NAME}{service_name=\"${SERVICE_NAME}\"}"
+# Build PromQL query. The service is the job label (from the resource's
+# service.name); implementations need not send a service_name metric label
+PROMQL_QUERY="${METRIC_NAME}{job=\"${SERVICE_NAME}\"}"
 
 # Query Prometheus
 if [[ "$JSON_MODE" == false ]]; then
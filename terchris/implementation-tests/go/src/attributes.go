@@ -0,0 +1,240 @@
+package sovdevlogger
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+
+	otlog "go.opentelemetry.io/otel/log"
+)
+
+const (
+	// defaultMaxFlattenedAttributes caps flattened keys per payload when Config.MaxFlattenedAttributes is 0
+	defaultMaxFlattenedAttributes = 32
+	// defaultAttributeCountLimit matches the OTEL SDK default for OTEL_LOGRECORD_ATTRIBUTE_COUNT_LIMIT
+	defaultAttributeCountLimit = 128
+	// defaultAttributeValueLength caps string attribute values when nothing else is configured
+	defaultAttributeValueLength = 4096
+	// maxFlattenDepth stops recursion into deeply nested payloads
+	maxFlattenDepth = 5
+)
+
+// flattenPayload turns the scalar fields of a JSON payload into OTLP attributes
+// prefixed with the given name, e.g. {"organisasjonsnummer": "123"} with prefix
+// "input" becomes input.organisasjonsnummer="123". Nested objects use dotted
+// paths; arrays and nulls are skipped (they remain in the full JSON attribute).
+// At most maxKeys attributes are returned, in sorted key order.
+func flattenPayload(prefix string, payloadJSON []byte, maxKeys int) []otlog.KeyValue {
+	if maxKeys <= 0 {
+		return nil
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(payloadJSON, &payload); err != nil {
+		return nil // Not a JSON object, nothing to flatten
+	}
+
+	var attrs []otlog.KeyValue
+	var walk func(path string, obj map[string]interface{}, depth int)
+	walk = func(path string, obj map[string]interface{}, depth int) {
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			if len(attrs) >= maxKeys {
+				return
+			}
+			key := path + "." + k
+			switch v := obj[k].(type) {
+			case string:
+				attrs = append(attrs, otlog.String(key, v))
+			case float64:
+				attrs = append(attrs, otlog.Float64(key, v))
+			case bool:
+				attrs = append(attrs, otlog.Bool(key, v))
+			case map[string]interface{}:
+				if depth < maxFlattenDepth {
+					walk(key, v, depth+1)
+				}
+			}
+		}
+	}
+	walk(prefix, payload, 1)
+
+	return attrs
+}
+
+// customAttributes converts the attributes passed to LogAttrs into OTLP
+// attributes in sorted key order. Keys already used by existing (the standard
+// sovdev attributes) are skipped so callers cannot overwrite them; objects and
+// arrays are encoded as JSON strings.
+func customAttributes(attributes map[string]interface{}, existing []otlog.KeyValue) []otlog.KeyValue {
+	reserved := make(map[string]bool, len(existing))
+	for _, kv := range existing {
+		reserved[kv.Key] = true
+	}
+
+	keys := make([]string, 0, len(attributes))
+	for k := range attributes {
+		if !reserved[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	attrs := make([]otlog.KeyValue, 0, len(keys))
+	for _, k := range keys {
+		switch v := attributes[k].(type) {
+		case nil:
+			continue
+		case string:
+			attrs = append(attrs, otlog.String(k, v))
+		case bool:
+			attrs = append(attrs, otlog.Bool(k, v))
+		case int:
+			attrs = append(attrs, otlog.Int(k, v))
+		case int64:
+			attrs = append(attrs, otlog.Int64(k, v))
+		case float64:
+			attrs = append(attrs, otlog.Float64(k, v))
+		case json.Number:
+			if i, err := v.Int64(); err == nil {
+				attrs = append(attrs, otlog.Int64(k, i))
+			} else if f, err := v.Float64(); err == nil {
+				attrs = append(attrs, otlog.Float64(k, f))
+			} else {
+				attrs = append(attrs, otlog.String(k, v.String()))
+			}
+		default:
+			if data, err := json.Marshal(v); err == nil {
+				attrs = append(attrs, otlog.String(k, string(data)))
+			}
+		}
+	}
+	return attrs
+}
+
+// entryBody returns the entry as an OTLP map value (Config.OTLPMapBody), with
+// the same field names as the JSON entry and payloads as nested maps
+func entryBody(entry StructuredLogEntry) (otlog.Value, error) {
+	fields, err := entryFields(entry)
+	if err != nil {
+		return otlog.Value{}, err
+	}
+	return bodyValue(fields), nil
+}
+
+// bodyValue converts a value decoded by entryFields to an OTLP value; map keys
+// are sorted so the body is stable. JSON nulls are left out of maps and arrays,
+// since the OTLP exporters cannot encode an empty value.
+func bodyValue(value interface{}) otlog.Value {
+	switch v := value.(type) {
+	case string:
+		return otlog.StringValue(v)
+	case bool:
+		return otlog.BoolValue(v)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return otlog.Int64Value(i)
+		}
+		if f, err := v.Float64(); err == nil {
+			return otlog.Float64Value(f)
+		}
+		return otlog.StringValue(v.String())
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		kvs := make([]otlog.KeyValue, 0, len(keys))
+		for _, k := range keys {
+			if v[k] != nil {
+				kvs = append(kvs, otlog.KeyValue{Key: k, Value: bodyValue(v[k])})
+			}
+		}
+		return otlog.MapValue(kvs...)
+	case []interface{}:
+		values := make([]otlog.Value, 0, len(v))
+		for _, item := range v {
+			if item != nil {
+				values = append(values, bodyValue(item))
+			}
+		}
+		return otlog.SliceValue(values...)
+	default:
+		return otlog.Value{}
+	}
+}
+
+// enforceAttributeLimits truncates string values longer than maxLength and caps
+// the number of attributes at maxCount. When attributes are dropped the last
+// slot is used for an "attributes_dropped" marker with the number removed.
+// The returned bool reports whether anything was truncated or dropped.
+func enforceAttributeLimits(attrs []otlog.KeyValue, maxLength, maxCount int) ([]otlog.KeyValue, bool) {
+	truncated := false
+
+	if maxCount > 0 && len(attrs) > maxCount {
+		dropped := len(attrs) - (maxCount - 1)
+		attrs = append(attrs[:maxCount-1], otlog.Int("attributes_dropped", dropped))
+		truncated = true
+	}
+
+	if maxLength > 0 {
+		for i, kv := range attrs {
+			if kv.Value.Kind() != otlog.KindString {
+				continue
+			}
+			if v := kv.Value.AsString(); len(v) > maxLength {
+				attrs[i] = otlog.String(kv.Key, truncateUTF8(v, maxLength)+truncationMarker)
+				truncated = true
+			}
+		}
+	}
+
+	return attrs, truncated
+}
+
+// truncationMarker is appended to values cut by the attribute length limit
+const truncationMarker = "... (truncated)"
+
+// truncateUTF8 cuts s to at most maxBytes bytes without splitting a multi-byte
+// character, which would leave invalid UTF-8 that backends reject or mangle
+func truncateUTF8(s string, maxBytes int) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	for maxBytes > 0 && !utf8.RuneStart(s[maxBytes]) {
+		maxBytes--
+	}
+	return s[:maxBytes]
+}
+
+// attributeCountLimit returns the configured attribute count cap,
+// OTEL_LOGRECORD_ATTRIBUTE_COUNT_LIMIT, or the SDK default
+func (l *Logger) attributeCountLimit() int {
+	if l.config.MaxAttributeCount > 0 {
+		return l.config.MaxAttributeCount
+	}
+	if v, err := strconv.Atoi(os.Getenv("OTEL_LOGRECORD_ATTRIBUTE_COUNT_LIMIT")); err == nil && v > 0 {
+		return v
+	}
+	return defaultAttributeCountLimit
+}
+
+// attributeValueLengthLimit returns the configured value length cap,
+// OTEL_LOGRECORD_ATTRIBUTE_VALUE_LENGTH_LIMIT, or 4KB
+func (l *Logger) attributeValueLengthLimit() int {
+	if l.config.MaxAttributeValueLength > 0 {
+		return l.config.MaxAttributeValueLength
+	}
+	if v, err := strconv.Atoi(os.Getenv("OTEL_LOGRECORD_ATTRIBUTE_VALUE_LENGTH_LIMIT")); err == nil && v > 0 {
+		return v
+	}
+	return defaultAttributeValueLength
+}
@@ -0,0 +1,139 @@
+package sovdevlogger
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	otlog "go.opentelemetry.io/otel/log"
+)
+
+func TestTruncateUTF8(t *testing.T) {
+	tests := []struct {
+		in   string
+		max  int
+		want string
+	}{
+		{"hello", 10, "hello"},
+		{"hello", 3, "hel"},
+		{"blåbær", 3, "bl"}, // "å" is two bytes; cutting at 3 would split it
+		{"blåbær", 4, "blå"},
+		{"€uro", 2, ""},
+		{"", 0, ""},
+	}
+	for _, tt := range tests {
+		got := truncateUTF8(tt.in, tt.max)
+		if got != tt.want {
+			t.Errorf("truncateUTF8(%q, %d) = %q, want %q", tt.in, tt.max, got, tt.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("truncateUTF8(%q, %d) = %q is not valid UTF-8", tt.in, tt.max, got)
+		}
+	}
+}
+
+func TestEnforceAttributeLimitsKeepsValidUTF8(t *testing.T) {
+	attrs := []otlog.KeyValue{otlog.String("message", strings.Repeat("æøå", 100))}
+
+	for maxLength := 1; maxLength < 20; maxLength++ {
+		got, truncated := enforceAttributeLimits(append([]otlog.KeyValue(nil), attrs...), maxLength, 0)
+		if !truncated {
+			t.Fatalf("maxLength %d: not reported as truncated", maxLength)
+		}
+		v := got[0].Value.AsString()
+		if !utf8.ValidString(v) {
+			t.Errorf("maxLength %d: %q is not valid UTF-8", maxLength, v)
+		}
+		if !strings.HasSuffix(v, truncationMarker) || len(v) > maxLength+len(truncationMarker) {
+			t.Errorf("maxLength %d: got %q", maxLength, v)
+		}
+	}
+}
+
+func TestInvalidTraceIDWarningKeepsValidUTF8(t *testing.T) {
+	l, sink := newTestLogger(t, Config{})
+
+	if err := l.Log(SOVDEV_LOGLEVELS.INFO, "TestTraceID", "Bad trace ID", "", nil, nil, nil, "x"+strings.Repeat("ø", 40)); err != nil {
+		t.Fatalf("Log: %v", err)
+	}
+	entries := sink.Entries()
+	warning := entries[len(entries)-1].ValidationWarning
+	if !strings.Contains(warning, "invalid trace_id") {
+		t.Fatalf("validation_warning = %q, want an invalid trace_id warning", warning)
+	}
+	if !utf8.ValidString(warning) || strings.Contains(warning, `\x`) {
+		t.Errorf("validation_warning %q contains a split character", warning)
+	}
+}
+
+func TestEnforceAttributeCountLimit(t *testing.T) {
+	var attrs []otlog.KeyValue
+	for i := 0; i < 10; i++ {
+		attrs = append(attrs, otlog.Int(fmt.Sprintf("attr_%d", i), i))
+	}
+
+	got, truncated := enforceAttributeLimits(append([]otlog.KeyValue(nil), attrs...), 0, 4)
+	if !truncated || len(got) != 4 {
+		t.Fatalf("got %d attributes (truncated=%v), want 4", len(got), truncated)
+	}
+	if last := got[3]; last.Key != "attributes_dropped" || last.Value.AsInt64() != 7 {
+		t.Errorf("last attribute = %s=%v, want attributes_dropped=7", last.Key, last.Value)
+	}
+	if got[2].Key != "attr_2" {
+		t.Errorf("kept attributes end with %s, want the first ones in order", got[2].Key)
+	}
+
+	if got, truncated := enforceAttributeLimits(append([]otlog.KeyValue(nil), attrs...), 0, 10); truncated || len(got) != 10 {
+		t.Errorf("at the limit: %d attributes, truncated=%v; want all kept", len(got), truncated)
+	}
+}
+
+func TestAttributeLimitSources(t *testing.T) {
+	t.Setenv("OTEL_LOGRECORD_ATTRIBUTE_COUNT_LIMIT", "")
+	t.Setenv("OTEL_LOGRECORD_ATTRIBUTE_VALUE_LENGTH_LIMIT", "")
+	l, _ := newTestLogger(t, Config{})
+	if l.attributeCountLimit() != defaultAttributeCountLimit || l.attributeValueLengthLimit() != defaultAttributeValueLength {
+		t.Errorf("defaults = %d, %d", l.attributeCountLimit(), l.attributeValueLengthLimit())
+	}
+
+	t.Setenv("OTEL_LOGRECORD_ATTRIBUTE_COUNT_LIMIT", "64")
+	t.Setenv("OTEL_LOGRECORD_ATTRIBUTE_VALUE_LENGTH_LIMIT", "1024")
+	if l.attributeCountLimit() != 64 || l.attributeValueLengthLimit() != 1024 {
+		t.Errorf("from env = %d, %d; want 64, 1024", l.attributeCountLimit(), l.attributeValueLengthLimit())
+	}
+
+	configured, _ := newTestLogger(t, Config{MaxAttributeCount: 32, MaxAttributeValueLength: 256})
+	if configured.attributeCountLimit() != 32 || configured.attributeValueLengthLimit() != 256 {
+		t.Errorf("from Config = %d, %d; want 32, 256", configured.attributeCountLimit(), configured.attributeValueLengthLimit())
+	}
+}
+
+func TestExportedRecordRespectsAttributeLimits(t *testing.T) {
+	l, _, collector := newCollectorLogger(t, Config{MaxAttributeCount: 8, MaxAttributeValueLength: 64})
+
+	input := map[string]interface{}{"name": strings.Repeat("Røde Kors ", 50)}
+	if err := l.Log(SOVDEV_LOGLEVELS.INFO, "TestAttributeLimits", "Limited", "", input, nil, nil, ""); err != nil {
+		t.Fatalf("Log: %v", err)
+	}
+	if err := l.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	record := exportedLogRecord(t, collector, "Limited")
+	if record == nil {
+		t.Fatal("log record not exported")
+	}
+	if len(record.Attributes) > 8 {
+		t.Errorf("record has %d attributes, want at most 8", len(record.Attributes))
+	}
+	for _, kv := range record.Attributes {
+		v := kv.Value.GetStringValue()
+		if len(v) > 64+len(truncationMarker) || !utf8.ValidString(v) {
+			t.Errorf("attribute %s = %q exceeds the length limit or is invalid UTF-8", kv.Key, v)
+		}
+	}
+	if last := record.Attributes[len(record.Attributes)-1]; last.Key != "attributes_dropped" {
+		t.Errorf("last attribute is %s, want attributes_dropped", last.Key)
+	}
+}
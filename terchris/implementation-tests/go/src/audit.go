@@ -0,0 +1,202 @@
+package sovdevlogger
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// auditGenesisHash is the prev_hash of the first entry in an audit log
+var auditGenesisHash = strings.Repeat("0", 64)
+
+// ErrAuditChainBroken is returned by SovdevVerifyAuditLog when an entry was
+// modified, removed, reordered or inserted
+var ErrAuditChainBroken = errors.New("audit log hash chain broken")
+
+// AuditLogWriter appends entries to a tamper-evident audit log: one JSON entry
+// per line, each carrying prev_hash (the entry_hash of the line before, 64
+// zeros for the first line) and entry_hash (see auditHash). The file is never
+// rotated or truncated; reopening it continues the existing chain.
+type AuditLogWriter struct {
+	mu       sync.Mutex
+	file     *os.File
+	prevHash string
+	// syncEachWrite fsyncs after every entry (Config.FileSyncEachWrite)
+	syncEachWrite bool
+}
+
+// NewAuditLogWriter opens (or creates) the audit log at path and reads its last
+// entry_hash so new entries extend the chain
+func NewAuditLogWriter(path string) (*AuditLogWriter, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0640)
+	if err != nil {
+		return nil, err
+	}
+
+	prevHash, err := lastAuditHash(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return &AuditLogWriter{file: file, prevHash: prevHash}, nil
+}
+
+// WriteEntry chains entry to the previous one and appends it
+func (w *AuditLogWriter) WriteEntry(level SovdevLogLevel, entry StructuredLogEntry) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return ErrSinkClosed
+	}
+
+	entry.PrevHash, entry.EntryHash = "", ""
+	data, err := marshalJSON(entry)
+	if err != nil {
+		return err
+	}
+	fields, ok := decodeJSONPayload(data)
+	if !ok {
+		return fmt.Errorf("audit entry is not a JSON object")
+	}
+	object := fields.(map[string]interface{})
+	entryHash, err := auditHash(object, w.prevHash)
+	if err != nil {
+		return err
+	}
+	object["prev_hash"] = w.prevHash
+	object["entry_hash"] = entryHash
+
+	line, err := marshalJSON(object)
+	if err != nil {
+		return err
+	}
+	if _, err := w.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if w.syncEachWrite {
+		if err := w.file.Sync(); err != nil {
+			return err
+		}
+	}
+	w.prevHash = entryHash
+	return nil
+}
+
+// Write appends entry to the chain (Sink)
+func (w *AuditLogWriter) Write(entry StructuredLogEntry) error {
+	return w.WriteEntry(SovdevLogLevel(entry.Level), entry)
+}
+
+// Close syncs and closes the file; later writes return ErrSinkClosed
+func (w *AuditLogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Sync()
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	w.file = nil
+	return err
+}
+
+// auditHash is hex(SHA-256(canonical JSON of the entry without prev_hash and
+// entry_hash, followed by prev_hash)). Canonical JSON is compact, with object
+// keys sorted, numbers as written and no HTML escaping, so an external tool can
+// recompute it from the logged line.
+func auditHash(object map[string]interface{}, prevHash string) (string, error) {
+	canonical, err := marshalJSON(object)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.New()
+	sum.Write(canonical)
+	sum.Write([]byte(prevHash))
+	return hex.EncodeToString(sum.Sum(nil)), nil
+}
+
+// lastAuditHash returns the entry_hash of the last line in an audit log, or the
+// genesis hash for an empty file
+func lastAuditHash(r io.Reader) (string, error) {
+	hash := auditGenesisHash
+	err := readAuditLines(r, func(number int, object map[string]interface{}) error {
+		h, _ := object["entry_hash"].(string)
+		if h == "" {
+			return fmt.Errorf("line %d has no entry_hash", number)
+		}
+		hash = h
+		return nil
+	})
+	return hash, err
+}
+
+// SovdevVerifyAuditLog reads an audit log written with Config.AuditLogPath and
+// checks every entry_hash and the prev_hash links between lines. It returns nil
+// for an intact log and an error wrapping ErrAuditChainBroken with the first
+// offending line number otherwise.
+func SovdevVerifyAuditLog(r io.Reader) error {
+	prevHash := auditGenesisHash
+	return readAuditLines(r, func(number int, object map[string]interface{}) error {
+		linkedHash, _ := object["prev_hash"].(string)
+		entryHash, _ := object["entry_hash"].(string)
+		delete(object, "prev_hash")
+		delete(object, "entry_hash")
+
+		if linkedHash != prevHash {
+			return fmt.Errorf("%w: line %d: prev_hash does not match the previous entry", ErrAuditChainBroken, number)
+		}
+		expected, err := auditHash(object, prevHash)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", number, err)
+		}
+		if entryHash != expected {
+			return fmt.Errorf("%w: line %d: entry_hash does not match its content", ErrAuditChainBroken, number)
+		}
+		prevHash = entryHash
+		return nil
+	})
+}
+
+// readAuditLines decodes each non-empty line as a JSON object and passes it to
+// fn with its 1-based line number
+func readAuditLines(r io.Reader, fn func(number int, object map[string]interface{}) error) error {
+	reader := bufio.NewReader(r)
+	for number := 1; ; number++ {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			decoder := json.NewDecoder(bytes.NewReader(line))
+			decoder.UseNumber()
+			var object map[string]interface{}
+			if decodeErr := decoder.Decode(&object); decodeErr != nil {
+				return fmt.Errorf("%w: line %d: %v", ErrAuditChainBroken, number, decodeErr)
+			}
+			if fnErr := fn(number, object); fnErr != nil {
+				return fnErr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
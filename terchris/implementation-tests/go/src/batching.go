@@ -0,0 +1,141 @@
+package sovdevlogger
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// batchQueue is the bounded queue behind the HTTP sinks (Splunk HEC,
+// Elasticsearch): entries are accepted without blocking and handed to send in
+// batches of up to batchSize, at least every interval, by one background
+// goroutine. While send is slow (e.g. retrying) the queue fills up and new
+// entries are rejected with ErrSinkQueueFull instead of blocking the caller.
+type batchQueue struct {
+	batchSize int
+	interval  time.Duration
+	send      func(batch []StructuredLogEntry)
+
+	mu       sync.RWMutex
+	closed   bool
+	queue    chan StructuredLogEntry
+	flushReq chan chan struct{}
+	done     chan struct{}
+}
+
+// newBatchQueue starts the goroutine calling send; the batch slice is reused
+// after send returns
+func newBatchQueue(queueSize, batchSize int, interval time.Duration, send func(batch []StructuredLogEntry)) *batchQueue {
+	q := &batchQueue{
+		batchSize: batchSize,
+		interval:  interval,
+		send:      send,
+		queue:     make(chan StructuredLogEntry, queueSize),
+		flushReq:  make(chan chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+// add queues an entry without blocking
+func (q *batchQueue) add(entry StructuredLogEntry) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return ErrSinkClosed
+	}
+	select {
+	case q.queue <- entry:
+		return nil
+	default:
+		return ErrSinkQueueFull
+	}
+}
+
+// flush sends all queued entries, waiting until done or ctx expires
+func (q *batchQueue) flush(ctx context.Context) error {
+	ack := make(chan struct{})
+	select {
+	case q.flushReq <- ack:
+	case <-q.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case <-ack:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close sends the remaining entries and stops the goroutine. Later adds return ErrSinkClosed.
+func (q *batchQueue) close(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.queue)
+	}
+	q.mu.Unlock()
+
+	select {
+	case <-q.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q *batchQueue) run() {
+	defer close(q.done)
+
+	ticker := time.NewTicker(q.interval)
+	defer ticker.Stop()
+
+	batch := make([]StructuredLogEntry, 0, q.batchSize)
+	send := func() {
+		if len(batch) > 0 {
+			q.send(batch)
+			batch = batch[:0]
+		}
+	}
+
+	for {
+		select {
+		case entry, ok := <-q.queue:
+			if !ok {
+				send()
+				return
+			}
+			batch = append(batch, entry)
+			if len(batch) >= q.batchSize {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		case ack := <-q.flushReq:
+			// Drain what was queued before the flush request
+			for drained := false; !drained; {
+				select {
+				case entry, ok := <-q.queue:
+					if !ok {
+						drained = true
+						break
+					}
+					batch = append(batch, entry)
+					if len(batch) >= q.batchSize {
+						send()
+					}
+				default:
+					drained = true
+				}
+			}
+			send()
+			close(ack)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package sovdevlogger
+
+import (
+	"runtime/debug"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// buildVersion reads the binary's embedded build info: the main module version
+// (or "dev" for local builds and binaries without module info) and the VCS
+// revision, commit time and dirty flag as resource attributes when present
+func buildVersion() (string, []attribute.KeyValue) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "dev", nil
+	}
+
+	version := info.Main.Version
+	if version == "" || version == "(devel)" {
+		version = "dev"
+	}
+
+	var vcs []attribute.KeyValue
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			vcs = append(vcs, attribute.String("vcs.revision", setting.Value))
+		case "vcs.time":
+			vcs = append(vcs, attribute.String("vcs.time", setting.Value))
+		case "vcs.modified":
+			vcs = append(vcs, attribute.Bool("vcs.modified", setting.Value == "true"))
+		}
+	}
+	return version, vcs
+}
@@ -0,0 +1,45 @@
+package sovdevlogger
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// packagePrefix matches function names of this package in stack frames
+var packagePrefix = reflect.TypeOf(Logger{}).PkgPath() + "."
+
+// callerLocation returns the source file and line of the first stack frame
+// outside this package, i.e. the code that called SovdevLog, Logger.Log or any
+// of the other wrappers. The file is shortened to its parent directory and
+// name (e.g. "company-lookup/main.go"). Entries logged by the library itself
+// from a background goroutine have no such frame and return "", 0.
+func callerLocation() (string, int) {
+	var pcs [32]uintptr
+	n := runtime.Callers(2, pcs[:]) // Skip runtime.Callers and callerLocation
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, packagePrefix) {
+			if strings.HasPrefix(frame.Function, "runtime.") {
+				return "", 0
+			}
+			return shortCallerFile(frame.File), frame.Line
+		}
+		if !more {
+			return "", 0
+		}
+	}
+}
+
+// shortCallerFile keeps the last directory and file name of path
+func shortCallerFile(path string) string {
+	idx := strings.LastIndexByte(path, '/')
+	if idx < 0 {
+		return path
+	}
+	if prev := strings.LastIndexByte(path[:idx], '/'); prev >= 0 {
+		return path[prev+1:]
+	}
+	return path
+}
@@ -0,0 +1,217 @@
+package sovdevlogger
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// contextKey is the private type for values sovdev-logger stores in a context
+type contextKey int
+
+const (
+	correlationIDKey contextKey = iota
+	tenantIDKey
+	peerServiceKey
+	jobNameKey
+	entryTimeKey
+	fieldsKey
+	systemIDKey
+	omitPayloadsKey
+)
+
+// SovdevWithCorrelationID returns a copy of ctx carrying a request-scoped correlation ID.
+// Logs written with SovdevLogContext use it as correlation_id, so all entries for
+// one request can be grouped even when they are written from worker goroutines.
+// session_id is unaffected and still identifies the process.
+func SovdevWithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey, id)
+}
+
+// correlationIDFromContext returns the correlation ID stored in ctx, or ""
+func correlationIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(correlationIDKey).(string)
+	return id
+}
+
+// SovdevWithTenant returns a copy of ctx carrying the tenant the request belongs to.
+// Logs written with SovdevLogContext include it as tenant_id; metrics get a tenant
+// attribute only when Config.TenantMetricLabels is set, since every tenant adds a
+// new time series to each instrument.
+func SovdevWithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantIDKey, tenantID)
+}
+
+// tenantIDFromContext returns the tenant ID stored in ctx, or ""
+func tenantIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(tenantIDKey).(string)
+	return id
+}
+
+// SovdevWithPeerService returns a copy of ctx carrying a default peer service
+// (friendly name or system ID, resolved like the explicit argument). Context-aware
+// log calls such as SovdevLogContext use it when their peerService argument is
+// empty, so a handler talking to one system does not repeat it on every call;
+// a non-empty argument still wins.
+func SovdevWithPeerService(ctx context.Context, peerService string) context.Context {
+	return context.WithValue(ctx, peerServiceKey, peerService)
+}
+
+// peerServiceOrDefault returns peerService, or the default stored in ctx when it is empty
+func peerServiceOrDefault(ctx context.Context, peerService string) string {
+	if peerService != "" || ctx == nil {
+		return peerService
+	}
+	fromContext, _ := ctx.Value(peerServiceKey).(string)
+	return fromContext
+}
+
+// SovdevTraceIDFromContext returns the trace ID of the active span in ctx as 32
+// lowercase hex characters, e.g. to return in a response header or error body so
+// a user report can be matched to the logs. Returns "" (never panics) when ctx is
+// nil or carries no valid span.
+func SovdevTraceIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+	return ""
+}
+
+// SovdevSpanIDFromContext returns the span ID of the active span in ctx as 16
+// lowercase hex characters, or "" when ctx is nil or carries no valid span
+func SovdevSpanIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasSpanID() {
+		return sc.SpanID().String()
+	}
+	return ""
+}
+
+// SovdevAppendField returns a copy of ctx with key set to value in its fields, so
+// middleware layers can add context as a request passes through them (request_id
+// at the edge, user_id after authentication). Context-aware log calls merge the
+// fields into input_json. A later append of the same key overrides the earlier
+// value; keys in the call's own input win over all of them. ctx itself is never
+// modified, so sibling handlers do not see each other's fields.
+func SovdevAppendField(ctx context.Context, key string, value interface{}) context.Context {
+	previous := fieldsFromContext(ctx)
+	fields := make(map[string]interface{}, len(previous)+1)
+	for k, v := range previous {
+		fields[k] = v
+	}
+	fields[key] = value
+	return context.WithValue(ctx, fieldsKey, fields)
+}
+
+// fieldsFromContext returns the fields added with SovdevAppendField, or nil.
+// The map is shared between contexts and must not be modified.
+func fieldsFromContext(ctx context.Context) map[string]interface{} {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(fieldsKey).(map[string]interface{})
+	return fields
+}
+
+// mergeContextFields adds the fields in ctx to a scrubbed input payload (see
+// scrubPayload): nil becomes an object of the fields, an object gets the fields
+// for keys it does not have, and other payloads (arrays, strings) are unchanged
+func mergeContextFields(ctx context.Context, input interface{}) interface{} {
+	fields := fieldsFromContext(ctx)
+	if len(fields) == 0 {
+		return input
+	}
+
+	switch val := input.(type) {
+	case nil:
+		return scrubPayload(fields)
+	case map[string]interface{}:
+		merged := scrubPayload(fields).(map[string]interface{})
+		for k, v := range val {
+			merged[k] = v
+		}
+		return merged
+	default:
+		return input
+	}
+}
+
+// omittedPayloads records which payloads SovdevWithoutPayloads drops
+type omittedPayloads struct {
+	input, response bool
+}
+
+// SovdevWithoutPayloads returns a copy of ctx that makes context-aware log calls
+// leave out input_json (omitInput) and/or response_json (omitResponse), e.g. for
+// a call whose input is a full document that must not be logged even redacted.
+// The rest of the entry, including attributes, is written as usual, with
+// attributes.payloads_omitted naming what was left out. The dropped payload is
+// never serialized, so it cannot reach any output; context fields
+// (SovdevAppendField) are dropped along with input_json.
+//
+// Loggeloven expects a transaction entry to show what was sent and received, so
+// omitting a payload is a deliberate data minimisation choice that should be
+// documented for the service (what is left out and why). payloads_omitted makes
+// the omission visible to auditors instead of looking like an empty payload.
+func SovdevWithoutPayloads(ctx context.Context, omitInput, omitResponse bool) context.Context {
+	return context.WithValue(ctx, omitPayloadsKey, omittedPayloads{input: omitInput, response: omitResponse})
+}
+
+// omittedPayloadsFromContext returns the payloads to leave out of entries logged with ctx
+func omittedPayloadsFromContext(ctx context.Context) omittedPayloads {
+	if ctx == nil {
+		return omittedPayloads{}
+	}
+	omitted, _ := ctx.Value(omitPayloadsKey).(omittedPayloads)
+	return omitted
+}
+
+// names returns the omitted payload fields as listed in payloads_omitted, or ""
+func (o omittedPayloads) names() string {
+	switch {
+	case o.input && o.response:
+		return "input_json,response_json"
+	case o.input:
+		return "input_json"
+	case o.response:
+		return "response_json"
+	}
+	return ""
+}
+
+// jobNameFromContext returns the job name set by SovdevStartJobSpan, or ""
+func jobNameFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	name, _ := ctx.Value(jobNameKey).(string)
+	return name
+}
+
+// withEntryTime returns a copy of ctx that makes the entry logged with it carry
+// t as its timestamp instead of the current time (used when replaying entries)
+func withEntryTime(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, entryTimeKey, t)
+}
+
+// entryTime returns the timestamp for an entry logged with ctx
+func entryTime(ctx context.Context) time.Time {
+	if ctx != nil {
+		if t, ok := ctx.Value(entryTimeKey).(time.Time); ok {
+			return t
+		}
+	}
+	return time.Now()
+}
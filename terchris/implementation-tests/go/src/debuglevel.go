@@ -0,0 +1,169 @@
+package sovdevlogger
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultDebugDuration is used when a debug request does not say how long
+	defaultDebugDuration = 10 * time.Minute
+	// maxDebugDuration bounds a single request, so a forgotten toggle cannot keep
+	// DEBUG on until the next deploy
+	maxDebugDuration = time.Hour
+)
+
+// debugOverride tracks a temporary min level lowered to DEBUG
+type debugOverride struct {
+	mu      sync.Mutex
+	timer   *time.Timer
+	restore int32 // min severity to go back to
+	until   time.Time
+	gen     int // identifies the current window, so a superseded timer does nothing
+}
+
+// SovdevEnableDebugFor temporarily lowers the default logger's min level to DEBUG
+// (see Logger.EnableDebugFor); returns when the window ends, or zero before initialization
+func SovdevEnableDebugFor(d time.Duration, by string) time.Time {
+	logger := defaultLogger()
+	if logger == nil {
+		return time.Time{}
+	}
+	return logger.EnableDebugFor(d, by)
+}
+
+// EnableDebugFor lowers the min level to DEBUG for d (capped at one hour), then
+// restores the previous level. Calling it again while active extends the window.
+// If the level is changed with SetMinLevel in the meantime, that level is kept.
+// The change and the revert are logged at WARN with by (who asked) so the
+// extra volume can be explained afterwards.
+func (l *Logger) EnableDebugFor(d time.Duration, by string) time.Time {
+	if d <= 0 {
+		d = defaultDebugDuration
+	}
+	if d > maxDebugDuration {
+		d = maxDebugDuration
+	}
+	debugSeverity := int32(mapToSeverityNumber(SOVDEV_LOGLEVELS.DEBUG))
+
+	o := &l.debugOverride
+	o.mu.Lock()
+	if o.timer != nil {
+		o.timer.Stop()
+	} else {
+		o.restore = l.minSeverity.Load()
+	}
+	previous := o.restore
+	if previous > debugSeverity {
+		l.minSeverity.Store(debugSeverity)
+	}
+	o.until = time.Now().Add(d)
+	o.gen++
+	until, gen := o.until, o.gen
+	o.timer = time.AfterFunc(d, func() {
+		o.mu.Lock()
+		if o.gen != gen {
+			o.mu.Unlock()
+			return
+		}
+		restore := o.restore
+		o.timer = nil
+		o.mu.Unlock()
+
+		// Only revert our own change
+		if restore > debugSeverity && l.minSeverity.CompareAndSwap(debugSeverity, restore) {
+			l.log(context.Background(), SOVDEV_LOGLEVELS.WARN, "sovdev.debug", "Temporary DEBUG level expired", l.selfPeerName,
+				map[string]interface{}{"min_level": string(severityToLevel(restore)), "requested_by": by}, nil, nil, "", SOVDEV_LOGTYPES.TRANSACTION)
+		}
+	})
+	o.mu.Unlock()
+
+	message := fmt.Sprintf("Min level temporarily lowered to debug for %s", d)
+	if previous <= debugSeverity {
+		message = fmt.Sprintf("DEBUG window of %s requested, min level already %s", d, severityToLevel(previous))
+	}
+	l.log(context.Background(), SOVDEV_LOGLEVELS.WARN, "sovdev.debug", message, l.selfPeerName,
+		map[string]interface{}{
+			"requested_by":   by,
+			"previous_level": string(severityToLevel(previous)),
+			"until":          until.UTC().Format(time.RFC3339),
+		}, nil, nil, "", SOVDEV_LOGTYPES.TRANSACTION)
+	return until
+}
+
+// severityToLevel maps an OTEL severity number back to its log level
+func severityToLevel(severity int32) SovdevLogLevel {
+	for _, level := range []SovdevLogLevel{SOVDEV_LOGLEVELS.TRACE, SOVDEV_LOGLEVELS.DEBUG, SOVDEV_LOGLEVELS.INFO,
+		SOVDEV_LOGLEVELS.WARN, SOVDEV_LOGLEVELS.ERROR, SOVDEV_LOGLEVELS.FATAL} {
+		if int32(mapToSeverityNumber(level)) >= severity {
+			return level
+		}
+	}
+	return SOVDEV_LOGLEVELS.FATAL
+}
+
+// handleDebugLevel serves POST on SovdevDebugHandler: lower the min level to
+// DEBUG for ?duration= (default 10m, max 1h). Requires
+// "Authorization: Bearer <Config.DebugToken>"; without a configured token the
+// endpoint is disabled. The caller is identified by ?by= (or X-Requested-By)
+// and the remote address.
+func (l *Logger) handleDebugLevel(w http.ResponseWriter, r *http.Request) {
+	token := l.config.DebugToken
+	if token == "" {
+		token = os.Getenv("SOVDEV_DEBUG_TOKEN")
+	}
+	if token == "" {
+		http.Error(w, "debug level endpoint disabled (no debug token configured)", http.StatusForbidden)
+		return
+	}
+	given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	d := defaultDebugDuration
+	if value := r.URL.Query().Get("duration"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			http.Error(w, fmt.Sprintf("invalid duration %q", value), http.StatusBadRequest)
+			return
+		}
+		d = parsed
+	}
+
+	by := r.URL.Query().Get("by")
+	if by == "" {
+		by = r.Header.Get("X-Requested-By")
+	}
+	if by == "" {
+		by = "unknown"
+	}
+	by = fmt.Sprintf("%s (%s)", scrubString(by), r.RemoteAddr)
+
+	until := l.EnableDebugFor(d, by)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"min_level": string(SOVDEV_LOGLEVELS.DEBUG),
+		"until":     until.UTC().Format(time.RFC3339),
+	})
+}
+
+// stop cancels a pending revert, e.g. on shutdown
+func (o *debugOverride) stop() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.timer != nil {
+		o.timer.Stop()
+		o.timer = nil
+	}
+	o.gen++
+}
@@ -0,0 +1,92 @@
+package sovdevlogger
+
+import (
+	"sync"
+	"time"
+)
+
+// dedupKey identifies entries that are considered identical
+type dedupKey struct {
+	tenantID     string
+	level        SovdevLogLevel
+	functionName string
+	message      string
+}
+
+// dedupState tracks one burst of identical entries
+type dedupState struct {
+	repeated int
+	level    SovdevLogLevel
+	last     StructuredLogEntry
+	timer    *time.Timer
+}
+
+// deduplicator collapses identical entries within a window (Config.DedupWindow).
+// The first entry of a burst is written immediately; repeats are suppressed and
+// reported as one summary entry with "repeated": N when the window ends.
+type deduplicator struct {
+	mu     sync.Mutex
+	window time.Duration
+	write  func(level SovdevLogLevel, entry StructuredLogEntry)
+	bursts map[dedupKey]*dedupState
+}
+
+func newDeduplicator(window time.Duration, write func(SovdevLogLevel, StructuredLogEntry)) *deduplicator {
+	return &deduplicator{
+		window: window,
+		write:  write,
+		bursts: make(map[dedupKey]*dedupState),
+	}
+}
+
+// suppress reports whether entry repeats one already written in the current
+// window and should therefore not be written now
+func (d *deduplicator) suppress(level SovdevLogLevel, entry StructuredLogEntry) bool {
+	key := dedupKey{tenantID: entry.TenantID, level: level, functionName: entry.FunctionName, message: entry.Message}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if state, ok := d.bursts[key]; ok {
+		state.repeated++
+		state.last = entry
+		return true
+	}
+
+	state := &dedupState{level: level}
+	state.timer = time.AfterFunc(d.window, func() { d.end(key, state) })
+	d.bursts[key] = state
+	return false
+}
+
+// end closes a burst and writes its summary entry if anything was suppressed
+func (d *deduplicator) end(key dedupKey, state *dedupState) {
+	d.mu.Lock()
+	if d.bursts[key] != state {
+		d.mu.Unlock()
+		return // Already ended by flush
+	}
+	delete(d.bursts, key)
+	repeated, level, entry := state.repeated, state.level, state.last
+	d.mu.Unlock()
+
+	if repeated > 0 {
+		entry.Repeated = repeated
+		d.write(level, entry)
+	}
+}
+
+// flush ends all open bursts now, e.g. before Flush or Shutdown
+func (d *deduplicator) flush() {
+	d.mu.Lock()
+	pending := make(map[dedupKey]*dedupState, len(d.bursts))
+	for key, state := range d.bursts {
+		state.timer.Stop()
+		pending[key] = state
+	}
+	d.mu.Unlock()
+
+	for key, state := range pending {
+		d.end(key, state)
+	}
+}
@@ -0,0 +1,119 @@
+package sovdevlogger
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// dedupEntries returns the entries sink received from functionName, in order
+func dedupEntries(sink *recordingSink, functionName string) []StructuredLogEntry {
+	var entries []StructuredLogEntry
+	for _, entry := range sink.Entries() {
+		if entry.FunctionName == functionName {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func TestDedupCollapsesBurstUntilFlush(t *testing.T) {
+	l, sink := newTestLogger(t, Config{DedupWindow: time.Hour})
+
+	for i := 0; i < 5; i++ {
+		if err := l.Log(SOVDEV_LOGLEVELS.ERROR, "TestDedup", "Lookup failed", "", nil, nil, errors.New("timeout"), ""); err != nil {
+			t.Fatalf("Log: %v", err)
+		}
+	}
+	// Each differs from the burst in one part of the key
+	_ = l.Log(SOVDEV_LOGLEVELS.ERROR, "TestDedup", "Other failure", "", nil, nil, nil, "")
+	_ = l.Log(SOVDEV_LOGLEVELS.WARN, "TestDedup", "Lookup failed", "", nil, nil, nil, "")
+	_ = l.Log(SOVDEV_LOGLEVELS.ERROR, "TestDedupOther", "Lookup failed", "", nil, nil, nil, "")
+
+	if got := len(dedupEntries(sink, "TestDedup")); got != 3 {
+		t.Fatalf("%d entries written during the burst, want 3 (first, other message, other level)", got)
+	}
+	if got := len(dedupEntries(sink, "TestDedupOther")); got != 1 {
+		t.Errorf("%d entries for another function name, want 1", got)
+	}
+
+	_ = l.Flush() // the export fails, as testEnv has no collector
+	entries := dedupEntries(sink, "TestDedup")
+	if len(entries) != 4 {
+		t.Fatalf("%d entries after Flush, want the burst summary added", len(entries))
+	}
+	summary := entries[3]
+	if summary.Message != "Lookup failed" || summary.Repeated != 4 {
+		t.Errorf("summary = %q repeated %d, want Lookup failed repeated 4", summary.Message, summary.Repeated)
+	}
+
+	// Flush ended the burst, so the next occurrence is written again
+	_ = l.Log(SOVDEV_LOGLEVELS.ERROR, "TestDedup", "Lookup failed", "", nil, nil, nil, "")
+	if entries := dedupEntries(sink, "TestDedup"); len(entries) != 5 || entries[4].Repeated != 0 {
+		t.Errorf("entry after Flush not written as a new burst: %d entries", len(entries))
+	}
+}
+
+func TestDedupWindowEnds(t *testing.T) {
+	l, sink := newTestLogger(t, Config{DedupWindow: 50 * time.Millisecond})
+
+	for i := 0; i < 3; i++ {
+		_ = l.Log(SOVDEV_LOGLEVELS.ERROR, "TestDedupWindow", "Lookup failed", "", nil, nil, nil, "")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(dedupEntries(sink, "TestDedupWindow")) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("no summary entry after the window ended")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if summary := dedupEntries(sink, "TestDedupWindow")[1]; summary.Repeated != 2 {
+		t.Errorf("summary repeated = %d, want 2", summary.Repeated)
+	}
+
+	_ = l.Log(SOVDEV_LOGLEVELS.ERROR, "TestDedupWindow", "Lookup failed", "", nil, nil, nil, "")
+	if got := len(dedupEntries(sink, "TestDedupWindow")); got != 3 {
+		t.Errorf("%d entries, want the first entry of a new window written", got)
+	}
+}
+
+func TestDedupSeparatesTenantsAndSkipsAudit(t *testing.T) {
+	l, sink := newTestLogger(t, Config{DedupWindow: time.Hour})
+
+	for _, tenant := range []string{"tenant-a", "tenant-b", "tenant-a"} {
+		ctx := SovdevWithTenant(context.Background(), tenant)
+		_ = l.LogContext(ctx, SOVDEV_LOGLEVELS.ERROR, "TestDedupTenant", "Lookup failed", "", nil, nil, nil, "")
+	}
+	if got := len(dedupEntries(sink, "TestDedupTenant")); got != 2 {
+		t.Errorf("%d entries for two tenants, want one each", got)
+	}
+
+	for i := 0; i < 3; i++ {
+		_ = l.LogTyped(SOVDEV_LOGTYPES.AUDIT, SOVDEV_LOGLEVELS.INFO, "TestDedupAudit", "Record exported", "", nil, nil, nil, "")
+	}
+	if got := len(dedupEntries(sink, "TestDedupAudit")); got != 3 {
+		t.Errorf("%d of 3 audit entries written, want all", got)
+	}
+}
+
+func TestDedupStillCountsEveryOccurrence(t *testing.T) {
+	l, _, collector := newCollectorLogger(t, Config{DedupWindow: time.Hour})
+
+	for i := 0; i < 5; i++ {
+		_ = l.Log(SOVDEV_LOGLEVELS.ERROR, "TestDedupMetrics", "Lookup failed", "", nil, nil, nil, "")
+	}
+	if err := l.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	m := exportedMetrics(t, collector)["sovdev.errors.total"]
+	var total int64
+	for _, dp := range m.GetSum().GetDataPoints() {
+		total += dp.GetAsInt()
+	}
+	if total != 5 {
+		t.Errorf("sovdev.errors.total = %d, want 5", total)
+	}
+}
@@ -0,0 +1,323 @@
+package sovdevlogger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// elasticsearchQueueSize bounds entries waiting to be sent; newer entries are dropped when full
+	elasticsearchQueueSize = 10000
+	// elasticsearchBatchSize is the maximum number of documents per _bulk request
+	elasticsearchBatchSize = 500
+	// elasticsearchBatchInterval sends partial batches at least this often
+	elasticsearchBatchInterval = 2 * time.Second
+	// elasticsearchMaxAttempts includes the first try; 429, 5xx and network errors are retried
+	elasticsearchMaxAttempts = 5
+	// elasticsearchMaxBackoff caps the wait between attempts, including Retry-After
+	elasticsearchMaxBackoff = 30 * time.Second
+	// defaultElasticsearchIndex gives one index per UTC day
+	defaultElasticsearchIndex = "sovdev-logs-YYYY.MM.DD"
+)
+
+// ElasticsearchSink ships entries to Elasticsearch or OpenSearch through the
+// _bulk API, as ECS-style documents (@timestamp, log.level, service.name,
+// trace.id, span.id, error.*; sovdev fields without an ECS equivalent under
+// sovdev.*). Entries are queued (bounded) and sent in batches by a background
+// goroutine. When the cluster pushes back with 429, the batch (or only the
+// rejected documents) is retried with backoff, honouring Retry-After; meanwhile
+// the queue fills and further entries are dropped with ErrSinkQueueFull rather
+// than blocking the caller. Documents are created with event_id as _id, so a
+// retried document that was already indexed is not duplicated.
+type ElasticsearchSink struct {
+	bulkURL  string
+	index    string
+	apiKey   string
+	host     string
+	client   *http.Client
+	throttle *selfLogThrottle
+	queue    *batchQueue
+}
+
+// NewElasticsearchSink creates a sink for the cluster at endpoint (e.g.
+// "https://elastic:9200"; basic auth credentials may be given in the URL).
+// index is the target index or data stream, where YYYY, MM and DD are replaced
+// by the entry's UTC date (default "sovdev-logs-YYYY.MM.DD"). apiKey, if set, is
+// sent as "Authorization: ApiKey <apiKey>" (the base64 encoded id:key).
+func NewElasticsearchSink(endpoint, index, apiKey string) (*ElasticsearchSink, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid Elasticsearch endpoint %q", endpoint)
+	}
+	if index == "" {
+		index = defaultElasticsearchIndex
+	}
+	if literal := strings.NewReplacer("YYYY", "", "MM", "", "DD", "").Replace(index); literal != strings.ToLower(literal) {
+		return nil, fmt.Errorf("invalid Elasticsearch index %q: must be lowercase", index)
+	}
+	u.Path = strings.TrimRight(u.Path, "/") + "/_bulk"
+
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	s := &ElasticsearchSink{
+		bulkURL:  u.String(),
+		index:    index,
+		apiKey:   apiKey,
+		host:     host,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		throttle: newSelfLogThrottle(selfLogInterval),
+	}
+	s.queue = newBatchQueue(elasticsearchQueueSize, elasticsearchBatchSize, elasticsearchBatchInterval, s.send)
+	return s, nil
+}
+
+// Write queues an entry without blocking
+func (s *ElasticsearchSink) Write(entry StructuredLogEntry) error {
+	return s.queue.add(entry)
+}
+
+// Flush sends all queued entries, waiting until done or ctx expires
+func (s *ElasticsearchSink) Flush(ctx context.Context) error {
+	return s.queue.flush(ctx)
+}
+
+// Close sends the remaining entries and stops the sink. Later writes return ErrSinkClosed.
+func (s *ElasticsearchSink) Close() error {
+	return s.CloseContext(context.Background())
+}
+
+// CloseContext is Close bounded by ctx
+func (s *ElasticsearchSink) CloseContext(ctx context.Context) error {
+	return s.queue.close(ctx)
+}
+
+// send indexes one batch, retrying what the cluster could not take yet
+func (s *ElasticsearchSink) send(batch []StructuredLogEntry) {
+	pending := append([]StructuredLogEntry(nil), batch...)
+	var err error
+	var wait time.Duration
+	for attempt := 0; attempt < elasticsearchMaxAttempts && len(pending) > 0; attempt++ {
+		if attempt > 0 {
+			if wait <= 0 {
+				wait = time.Duration(1<<(attempt-1)) * 500 * time.Millisecond
+			}
+			time.Sleep(min(wait, elasticsearchMaxBackoff))
+		}
+		pending, wait, err = s.bulk(pending)
+		if err != nil && pending == nil {
+			break
+		}
+	}
+
+	if err != nil {
+		dropped := len(pending)
+		if dropped == 0 {
+			dropped = len(batch)
+		}
+		if ok, suppressed := s.throttle.allow("send"); ok {
+			fmt.Printf("⚠️  Elasticsearch: dropped %d entries: %v (%d similar failures suppressed)\n", dropped, err, suppressed)
+		}
+	}
+}
+
+// bulkResponse is the part of the _bulk response needed to find rejected documents
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int `json:"status"`
+		Error  struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+// bulk sends entries once. It returns the entries worth retrying (all of them on
+// 429, 5xx or a network error; only the rejected documents on a partial failure)
+// with the server's Retry-After, or nil retry entries when the failure is final.
+func (s *ElasticsearchSink) bulk(entries []StructuredLogEntry) ([]StructuredLogEntry, time.Duration, error) {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, entry := range entries {
+		action := map[string]interface{}{"_index": s.indexFor(entry)}
+		if entry.EventID != "" {
+			action["_id"] = entry.EventID
+		}
+		encoder.Encode(map[string]interface{}{"create": action})
+		encoder.Encode(s.document(entry))
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.bulkURL, &body)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if s.apiKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+s.apiKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return entries, 0, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		io.Copy(io.Discard, resp.Body)
+		return entries, retryAfter(resp), fmt.Errorf("_bulk returned %s", resp.Status)
+	case resp.StatusCode >= 300:
+		io.Copy(io.Discard, resp.Body)
+		return nil, 0, fmt.Errorf("_bulk returned %s", resp.Status)
+	}
+
+	var result bulkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, 0, fmt.Errorf("decode _bulk response: %w", err)
+	}
+	if !result.Errors {
+		return nil, 0, nil
+	}
+
+	// Retry documents the cluster had no room for; a conflict means an earlier
+	// attempt already created the document, anything else (e.g. a mapping
+	// error) will not succeed on retry
+	var retry []StructuredLogEntry
+	var rejected int
+	var reason string
+	for i, item := range result.Items {
+		for _, outcome := range item {
+			switch {
+			case outcome.Status < 300 || outcome.Status == http.StatusConflict:
+			case outcome.Status == http.StatusTooManyRequests && i < len(entries):
+				retry = append(retry, entries[i])
+			default:
+				rejected++
+				if reason == "" {
+					reason = fmt.Sprintf("%s: %s", outcome.Error.Type, outcome.Error.Reason)
+				}
+			}
+		}
+	}
+	if rejected > 0 {
+		if ok, suppressed := s.throttle.allow("rejected"); ok {
+			fmt.Printf("⚠️  Elasticsearch: rejected %d entries: %s (%d similar failures suppressed)\n", rejected, reason, suppressed)
+		}
+	}
+	if len(retry) > 0 {
+		return retry, 0, fmt.Errorf("%d documents rejected with 429", len(retry))
+	}
+	return nil, 0, nil
+}
+
+// retryAfter returns the Retry-After delay in seconds, or zero if absent
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// indexFor expands YYYY, MM and DD in the index pattern with the entry's UTC date
+func (s *ElasticsearchSink) indexFor(entry StructuredLogEntry) string {
+	if !strings.Contains(s.index, "YYYY") && !strings.Contains(s.index, "MM") && !strings.Contains(s.index, "DD") {
+		return s.index
+	}
+	ts, err := time.Parse(time.RFC3339Nano, entry.Timestamp)
+	if err != nil {
+		ts = time.Now()
+	}
+	ts = ts.UTC()
+	return strings.NewReplacer(
+		"YYYY", ts.Format("2006"),
+		"MM", ts.Format("01"),
+		"DD", ts.Format("02"),
+	).Replace(s.index)
+}
+
+// document maps an entry to ECS field names; dotted keys are expanded to
+// objects by Elasticsearch and OpenSearch. Payloads are stored as JSON strings,
+// like the OTLP attributes, so differing payload shapes across services cannot
+// cause mapping conflicts.
+func (s *ElasticsearchSink) document(entry StructuredLogEntry) map[string]interface{} {
+	doc := map[string]interface{}{
+		"@timestamp":  entry.Timestamp,
+		"message":     entry.Message,
+		"ecs.version": "8.11.0",
+		"host.name":   s.host,
+	}
+
+	fields := map[string]string{
+		"log.level":                 entry.Level,
+		"log.origin.function":       entry.FunctionName,
+		"log.origin.file.name":      entry.CallerFile,
+		"service.name":              entry.ServiceName,
+		"service.version":           entry.ServiceVersion,
+		"trace.id":                  entry.TraceID,
+		"span.id":                   entry.SpanID,
+		"event.id":                  entry.EventID,
+		"error.type":                entry.ExceptionType,
+		"error.code":                entry.ExceptionCode,
+		"error.message":             entry.ExceptionMessage,
+		"error.stack_trace":         entry.ExceptionStacktrace,
+		"sovdev.schema_version":     entry.SchemaVersion,
+		"sovdev.session_id":         entry.SessionID,
+		"sovdev.correlation_id":     entry.CorrelationID,
+		"sovdev.tenant_id":          entry.TenantID,
+		"sovdev.peer_service":       entry.PeerService,
+		"sovdev.log_type":           string(entry.LogType),
+		"sovdev.validation_warning": entry.ValidationWarning,
+		"sovdev.prev_hash":          entry.PrevHash,
+		"sovdev.entry_hash":         entry.EntryHash,
+	}
+	for name, value := range fields {
+		if value != "" {
+			doc[name] = value
+		}
+	}
+	if entry.InputJSON != nil {
+		if data, err := payloadJSON(entry.InputJSON); err == nil {
+			doc["sovdev.input_json"] = string(data)
+		}
+	}
+	if entry.ResponseJSON != nil {
+		if data, err := payloadJSON(entry.ResponseJSON); err == nil {
+			doc["sovdev.response_json"] = string(data)
+		}
+	}
+	if entry.CallerLine > 0 {
+		doc["log.origin.file.line"] = entry.CallerLine
+	}
+	if entry.Repeated > 0 {
+		doc["sovdev.repeated"] = entry.Repeated
+	}
+	if len(entry.ExceptionChain) > 0 {
+		doc["error.chain"] = entry.ExceptionChain
+	}
+	if len(entry.Attributes) > 0 {
+		doc["sovdev.attributes"] = entry.Attributes
+	}
+	return doc
+}
+
+// redactURL hides the password of a URL with credentials, for printing
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	return u.Redacted()
+}
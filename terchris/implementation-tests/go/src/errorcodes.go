@@ -0,0 +1,106 @@
+package sovdevlogger
+
+import (
+	"errors"
+	"fmt"
+)
+
+// CodedError is implemented by errors that carry a stable code (e.g. ERR_BRREG_TIMEOUT).
+// When a logged exception, or any error it wraps, implements it, the code is
+// written as exception_code so backends can group and alert on it instead of on
+// free-text messages.
+type CodedError interface {
+	error
+	Code() string
+}
+
+// AttributedError is implemented by errors that carry structured context (e.g.
+// an HTTP status or retry count). When a logged exception, or any error it
+// wraps, implements it, the attributes are added to the entry's attributes as
+// with SovdevLogAttrs; attributes passed to the call win over the error's, and
+// an outer error's over those of the errors it wraps.
+type AttributedError interface {
+	error
+	Attributes() map[string]interface{}
+}
+
+// SovdevErrorWithCode wraps err with a stable code. The result still matches
+// err with errors.Is/As and keeps err's message:
+//
+//	if err != nil {
+//	    return sovdevlogger.SovdevErrorWithCode("ERR_BRREG_TIMEOUT", err)
+//	}
+func SovdevErrorWithCode(code string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &codedError{code: code, err: err}
+}
+
+// codedError is the CodedError returned by SovdevErrorWithCode
+type codedError struct {
+	code string
+	err  error
+}
+
+func (e *codedError) Error() string { return e.err.Error() }
+func (e *codedError) Code() string  { return e.code }
+func (e *codedError) Unwrap() error { return e.err }
+
+// exceptionCodeOf returns the code of the outermost CodedError in err's chain, or ""
+func exceptionCodeOf(err error) string {
+	var coded CodedError
+	if errors.As(err, &coded) {
+		return coded.Code()
+	}
+	return ""
+}
+
+// maxExceptionChainLength caps exception_chain, so an error whose Unwrap returns
+// itself (or cycles) cannot loop forever
+const maxExceptionChainLength = 16
+
+// ExceptionLink is one error in exception_chain: its Go type and its own message
+type ExceptionLink struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// errorChain walks err with errors.Unwrap, outermost first, passing each
+// message through redact. Errors joining several others (errors.Join) end the
+// chain, since errors.Unwrap does not follow them.
+func errorChain(err error, redact func(string) string) []ExceptionLink {
+	var chain []ExceptionLink
+	for ; err != nil && len(chain) < maxExceptionChainLength; err = errors.Unwrap(err) {
+		chain = append(chain, ExceptionLink{Type: fmt.Sprintf("%T", err), Message: redact(err.Error())})
+	}
+	return chain
+}
+
+// errorAttributes merges the Attributes of every AttributedError in err's chain
+// (following errors.Unwrap like errorChain) into attributes, without overwriting
+// keys already present. attributes itself is not modified.
+func errorAttributes(err error, attributes map[string]interface{}) map[string]interface{} {
+	merged := attributes
+	copied := false
+	for depth := 0; err != nil && depth < maxExceptionChainLength; err, depth = errors.Unwrap(err), depth+1 {
+		attributed, ok := err.(AttributedError)
+		if !ok {
+			continue
+		}
+		for k, v := range attributed.Attributes() {
+			if _, exists := merged[k]; exists {
+				continue
+			}
+			if !copied {
+				merged = make(map[string]interface{}, len(attributes)+1)
+				for key, value := range attributes {
+					merged[key] = value
+				}
+				copied = true
+			}
+			merged[k] = v
+		}
+	}
+	return merged
+}
@@ -0,0 +1,74 @@
+package sovdevlogger
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// cyclicError unwraps to itself
+type cyclicError struct{}
+
+func (e *cyclicError) Error() string { return "cycle" }
+func (e *cyclicError) Unwrap() error { return e }
+
+func TestErrorChain(t *testing.T) {
+	root := errors.New("connection refused, password=hunter2")
+	mid := fmt.Errorf("GET /enheter: %w", root)
+	top := SovdevErrorWithCode("ERR_BRREG_TIMEOUT", mid)
+
+	chain := errorChain(top, removeCredentials)
+	want := []ExceptionLink{
+		{Type: "*sovdevlogger.codedError", Message: "GET /enheter: connection refused, password: [REDACTED]"},
+		{Type: "*fmt.wrapError", Message: "GET /enheter: connection refused, password: [REDACTED]"},
+		{Type: "*errors.errorString", Message: "connection refused, password: [REDACTED]"},
+	}
+	if len(chain) != len(want) {
+		t.Fatalf("chain = %v, want %d links", chain, len(want))
+	}
+	for i := range want {
+		if chain[i] != want[i] {
+			t.Errorf("link %d = %+v, want %+v", i, chain[i], want[i])
+		}
+	}
+}
+
+func TestErrorChainIsCapped(t *testing.T) {
+	if got := len(errorChain(&cyclicError{}, func(s string) string { return s })); got != maxExceptionChainLength {
+		t.Errorf("cyclic error gave %d links, want the cap %d", got, maxExceptionChainLength)
+	}
+}
+
+func TestLoggedErrorChain(t *testing.T) {
+	err := fmt.Errorf("import failed: %w", fmt.Errorf("lookup company: %w", errors.New("Bearer s3cr3t-token rejected")))
+
+	for _, include := range []bool{false, true} {
+		t.Run(fmt.Sprintf("IncludeErrorChain=%v", include), func(t *testing.T) {
+			t.Setenv("SOVDEV_INCLUDE_ERROR_CHAIN", "")
+			l, sink := newTestLogger(t, Config{IncludeErrorChain: include})
+			if logErr := l.Log(SOVDEV_LOGLEVELS.ERROR, "TestErrorChain", "Import failed", "", nil, nil, err, ""); logErr != nil {
+				t.Fatalf("Log: %v", logErr)
+			}
+
+			chain := sink.Entries()[len(sink.Entries())-1].ExceptionChain
+			if !include {
+				if chain != nil {
+					t.Errorf("exception_chain = %v without IncludeErrorChain", chain)
+				}
+				return
+			}
+			if len(chain) != 3 {
+				t.Fatalf("exception_chain = %v, want 3 links", chain)
+			}
+			for _, link := range chain {
+				if strings.Contains(link.Message, "s3cr3t-token") {
+					t.Errorf("link leaks the token: %+v", link)
+				}
+			}
+			if chain[2].Message != "Bearer [REDACTED] rejected" {
+				t.Errorf("root link = %+v", chain[2])
+			}
+		})
+	}
+}
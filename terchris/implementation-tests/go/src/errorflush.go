@@ -0,0 +1,122 @@
+package sovdevlogger
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	// errorFlushInterval is the minimum time between flushes triggered by FlushOnLevel,
+	// so a burst of errors cannot flood the collector with tiny batches
+	errorFlushInterval = 5 * time.Second
+	// errorFlushTimeout bounds a single triggered flush
+	errorFlushTimeout = 5 * time.Second
+	// minFlushInterval is the shortest accepted Config.FlushInterval
+	minFlushInterval = time.Second
+)
+
+// flushAfter starts an asynchronous flush of the log and trace providers when
+// level is at or above Config.FlushOnLevel, unless one is running or ran within
+// errorFlushInterval
+func (l *Logger) flushAfter(level SovdevLogLevel) {
+	if l.flushOnSeverity == 0 || int32(mapToSeverityNumber(level)) < l.flushOnSeverity {
+		return
+	}
+
+	now := time.Now().UnixNano()
+	last := l.lastErrorFlush.Load()
+	if now-last < int64(errorFlushInterval) || !l.lastErrorFlush.CompareAndSwap(last, now) {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), errorFlushTimeout)
+		defer cancel()
+
+		if l.logProvider != nil {
+			l.logProvider.ForceFlush(ctx)
+		}
+		if l.traceProvider != nil {
+			l.traceProvider.ForceFlush(ctx)
+		}
+	}()
+}
+
+// periodicFlusher flushes a logger's outputs every Config.FlushInterval until stopped
+type periodicFlusher struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+// flushInterval returns Config.FlushInterval, SOVDEV_FLUSH_INTERVAL or zero (off)
+func flushInterval(cfg Config) time.Duration {
+	interval := cfg.FlushInterval
+	if interval == 0 {
+		if value := os.Getenv("SOVDEV_FLUSH_INTERVAL"); value != "" {
+			parsed, err := time.ParseDuration(value)
+			if err != nil {
+				fmt.Printf("⚠️  Invalid SOVDEV_FLUSH_INTERVAL %q, periodic flush disabled\n", value)
+				return 0
+			}
+			interval = parsed
+		}
+	}
+	if interval > 0 && interval < minFlushInterval {
+		interval = minFlushInterval
+	}
+	return interval
+}
+
+// startPeriodicFlush flushes the log, trace and metric providers and buffering
+// sinks every interval, quietly (unlike SovdevFlush), so a long batch job that
+// is killed loses at most one interval of telemetry
+func (l *Logger) startPeriodicFlush(interval time.Duration) *periodicFlusher {
+	f := &periodicFlusher{stop: make(chan struct{}), done: make(chan struct{})}
+	go func() {
+		defer close(f.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-f.stop:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), min(interval, defaultFlushTimeout))
+				l.flushQuietly(ctx)
+				cancel()
+			}
+		}
+	}()
+	return f
+}
+
+// flushQuietly is FlushContext without progress output; export failures are
+// reported by the export failure handler
+func (l *Logger) flushQuietly(ctx context.Context) {
+	if l.traceProvider != nil {
+		l.traceProvider.ForceFlush(ctx)
+	}
+	if l.meterProvider != nil {
+		l.meterProvider.ForceFlush(ctx)
+	}
+	for _, s := range l.sinks {
+		if f, ok := s.sink.(sinkFlusher); ok {
+			f.Flush(ctx)
+		}
+	}
+	if l.logProvider != nil {
+		l.logProvider.ForceFlush(ctx)
+	}
+}
+
+// close stops the ticker and waits for a flush in progress to finish
+func (f *periodicFlusher) close() {
+	if f == nil {
+		return
+	}
+	close(f.stop)
+	<-f.done
+}
@@ -0,0 +1,119 @@
+package sovdevlogger
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// selfLogInterval is the minimum time between WARN entries about the same
+// telemetry problem. When the collector is down every batch fails, and logging
+// each failure would itself produce more batches to fail.
+const selfLogInterval = time.Minute
+
+// selfLogThrottle lets one entry per key through per interval and counts the rest
+type selfLogThrottle struct {
+	mu       sync.Mutex
+	interval time.Duration
+	last     map[string]time.Time
+	skipped  map[string]int
+}
+
+func newSelfLogThrottle(interval time.Duration) *selfLogThrottle {
+	return &selfLogThrottle{
+		interval: interval,
+		last:     make(map[string]time.Time),
+		skipped:  make(map[string]int),
+	}
+}
+
+// allow reports whether an entry for key may be logged now, and how many
+// entries for key were suppressed since the last one that was
+func (t *selfLogThrottle) allow(key string) (bool, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if last, ok := t.last[key]; ok && now.Sub(last) < t.interval {
+		t.skipped[key]++
+		return false, 0
+	}
+	suppressed := t.skipped[key]
+	t.last[key] = now
+	t.skipped[key] = 0
+	return true, suppressed
+}
+
+// recordExportFailure counts a failed OTLP export for signal (traces, logs or
+// metrics) and logs a throttled WARN
+func (l *Logger) recordExportFailure(ctx context.Context, signal string, err error) {
+	l.stats.exportFailed(signal)
+	if l.exportFailures != nil {
+		l.exportFailures.Add(ctx, 1, metric.WithAttributes(attribute.String("signal", signal)))
+	}
+
+	ok, suppressed := l.selfLogThrottle.allow("export." + signal)
+	if !ok {
+		return
+	}
+	input := map[string]interface{}{
+		"signal":     signal,
+		"suppressed": suppressed,
+	}
+	l.log(context.Background(), SOVDEV_LOGLEVELS.WARN, "otel.export", "OTLP "+signal+" export failed", l.selfPeerName, input, nil, err, "", logTypeOTELInternal)
+}
+
+// failureTrackingSpanExporter reports ExportSpans results to the logger
+type failureTrackingSpanExporter struct {
+	sdktrace.SpanExporter
+	logger *Logger
+}
+
+func (e *failureTrackingSpanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	err := e.SpanExporter.ExportSpans(ctx, spans)
+	if err != nil {
+		e.logger.recordExportFailure(ctx, "traces", err)
+	} else {
+		e.logger.stats.exported("traces")
+	}
+	return err
+}
+
+// failureTrackingLogExporter reports Export results to the logger
+type failureTrackingLogExporter struct {
+	sdklog.Exporter
+	logger *Logger
+}
+
+func (e *failureTrackingLogExporter) Export(ctx context.Context, records []sdklog.Record) error {
+	err := e.Exporter.Export(ctx, records)
+	if err != nil {
+		e.logger.recordExportFailure(ctx, "logs", err)
+	} else {
+		e.logger.stats.exported("logs")
+	}
+	return err
+}
+
+// failureTrackingMetricExporter reports Export results to the logger
+type failureTrackingMetricExporter struct {
+	sdkmetric.Exporter
+	logger *Logger
+}
+
+func (e *failureTrackingMetricExporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	err := e.Exporter.Export(ctx, rm)
+	if err != nil {
+		e.logger.recordExportFailure(ctx, "metrics", err)
+	} else {
+		e.logger.stats.exported("metrics")
+	}
+	return err
+}
@@ -0,0 +1,200 @@
+package sovdevlogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Formatter renders an entry for the console or file output; the result is
+// written as one line (plus any lines the formatter embeds)
+type Formatter interface {
+	Format(entry StructuredLogEntry) ([]byte, error)
+}
+
+// JSONFormatter writes the entry as compact JSON, matching the log entry schema.
+// This is the default for both console and file.
+type JSONFormatter struct{}
+
+// Format implements Formatter
+func (JSONFormatter) Format(entry StructuredLogEntry) ([]byte, error) {
+	return marshalJSON(entry)
+}
+
+// LogfmtFormatter writes the entry as logfmt (key=value pairs in schema field
+// order); objects such as input_json are embedded as quoted JSON
+type LogfmtFormatter struct{}
+
+// Format implements Formatter
+func (LogfmtFormatter) Format(entry StructuredLogEntry) ([]byte, error) {
+	fields, err := entryFields(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	for _, key := range entryFieldOrder {
+		value, ok := fields[key]
+		if !ok {
+			continue
+		}
+		if buf.Len() > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(key)
+		buf.WriteByte('=')
+		buf.WriteString(logfmtValue(value))
+	}
+	return buf.Bytes(), nil
+}
+
+// PrettyFormatter writes a human-oriented line: time, level, peer, function and
+// message, followed by indented lines for payloads, attributes and exceptions.
+// Not machine-readable; meant for the console during development.
+type PrettyFormatter struct {
+	// Color highlights the level with ANSI colors
+	Color bool
+}
+
+// Format implements Formatter
+func (f PrettyFormatter) Format(entry StructuredLogEntry) ([]byte, error) {
+	var buf bytes.Buffer
+
+	clock := entry.Timestamp
+	if ts, err := time.Parse(time.RFC3339Nano, entry.Timestamp); err == nil {
+		clock = ts.Local().Format("15:04:05.000")
+	}
+	level := fmt.Sprintf("%-5s", strings.ToUpper(entry.Level))
+	if color := prettyLevelColors[entry.Level]; f.Color && color != "" {
+		level = color + level + "\x1b[0m"
+	}
+
+	// Free-text fields are written raw; escape them so none can split or forge
+	// a line. Payloads below are JSON, which escapes control characters itself.
+	fmt.Fprintf(&buf, "%s %s [%s] %s: %s", clock, level, escapeControlChars(entry.PeerService),
+		escapeControlChars(entry.FunctionName), escapeControlChars(entry.Message))
+	if entry.Repeated > 0 {
+		fmt.Fprintf(&buf, " (repeated %d)", entry.Repeated)
+	}
+	if entry.TraceID != "" {
+		fmt.Fprintf(&buf, " trace_id=%s", entry.TraceID)
+	}
+
+	detail := func(name string, value interface{}) {
+		data, err := json.Marshal(value)
+		if err != nil {
+			data = []byte(fmt.Sprint(value))
+		}
+		fmt.Fprintf(&buf, "\n    %s: %s", name, data)
+	}
+	if entry.InputJSON != nil {
+		detail("input", entry.InputJSON)
+	}
+	if entry.ResponseJSON != nil {
+		detail("response", entry.ResponseJSON)
+	}
+	if len(entry.Attributes) > 0 {
+		detail("attributes", entry.Attributes)
+	}
+	if entry.ExceptionType != "" || entry.ExceptionMessage != "" {
+		exception := entry.ExceptionType
+		if entry.ExceptionCode != "" {
+			exception += " " + entry.ExceptionCode
+		}
+		fmt.Fprintf(&buf, "\n    exception: %s: %s", escapeControlChars(exception), escapeControlChars(entry.ExceptionMessage))
+		if entry.ExceptionStacktrace != "" {
+			fmt.Fprintf(&buf, "\n    %s", strings.ReplaceAll(entry.ExceptionStacktrace, "\n", "\n    "))
+		}
+	}
+	if entry.ValidationWarning != "" {
+		fmt.Fprintf(&buf, "\n    validation_warning: %s", escapeControlChars(entry.ValidationWarning))
+	}
+	return buf.Bytes(), nil
+}
+
+// prettyLevelColors are the ANSI colors used by PrettyFormatter
+var prettyLevelColors = map[string]string{
+	"trace": "\x1b[90m",
+	"debug": "\x1b[36m",
+	"info":  "\x1b[32m",
+	"warn":  "\x1b[33m",
+	"error": "\x1b[31m",
+	"fatal": "\x1b[1;31m",
+}
+
+// formatterFromEnv maps a format name (json, logfmt, pretty) to a Formatter.
+// Empty or unknown names use JSONFormatter; pretty output is colored when
+// stdout is a terminal and NO_COLOR is unset.
+func formatterFromEnv(name string) Formatter {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "json":
+		return JSONFormatter{}
+	case "logfmt":
+		return LogfmtFormatter{}
+	case "pretty":
+		return PrettyFormatter{Color: os.Getenv("NO_COLOR") == "" && isTerminal(os.Stdout)}
+	}
+	fmt.Printf("⚠️  Unknown log format %q, using json\n", name)
+	return JSONFormatter{}
+}
+
+// isTerminal reports whether f is a character device such as an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// entryFieldOrder lists the JSON field names of StructuredLogEntry in declaration order
+var entryFieldOrder = func() []string {
+	t := reflect.TypeOf(StructuredLogEntry{})
+	names := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		names = append(names, name)
+	}
+	return names
+}()
+
+// entryFields returns the entry's JSON fields by name, with omitempty applied
+func entryFields(entry StructuredLogEntry) (map[string]interface{}, error) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]interface{})
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// logfmtValue renders a decoded JSON value; strings are quoted when they
+// contain spaces, quotes, '=' or control characters, objects become quoted JSON
+func logfmtValue(value interface{}) string {
+	var s string
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		s = v
+	case json.Number, bool:
+		return fmt.Sprint(v)
+	default:
+		// Objects and arrays; encoding/json sorts map keys, so output is stable
+		data, _ := json.Marshal(v)
+		s = string(data)
+	}
+
+	if s == "" || strings.ContainsAny(s, " \"=\\") || !utf8.ValidString(s) || strings.IndexFunc(s, isControlChar) >= 0 {
+		return strconv.Quote(s)
+	}
+	return s
+}
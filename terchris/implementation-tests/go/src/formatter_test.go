@@ -0,0 +1,121 @@
+package sovdevlogger
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// injectedEntry has a line break in every free-text field
+func injectedEntry() StructuredLogEntry {
+	return StructuredLogEntry{
+		Timestamp:         "2026-01-02T03:04:05.000Z",
+		Level:             "info",
+		ServiceName:       "sovdev-test",
+		PeerService:       "peer\ninfo forged",
+		FunctionName:      "lookup\r\nfatal forged",
+		Message:           "first line\nsecond line",
+		LogType:           SOVDEV_LOGTYPES.TRANSACTION,
+		ExceptionType:     "Error\nforged",
+		ExceptionMessage:  "failed\r\nforged",
+		ValidationWarning: "warning\nforged",
+	}
+}
+
+func TestFormattersKeepEntryOnOneLine(t *testing.T) {
+	formatters := map[string]Formatter{
+		"logfmt": LogfmtFormatter{},
+		"json":   JSONFormatter{},
+	}
+	for name, f := range formatters {
+		t.Run(name, func(t *testing.T) {
+			out, err := f.Format(injectedEntry())
+			if err != nil {
+				t.Fatalf("Format: %v", err)
+			}
+			if bytes.ContainsAny(out, "\r\n") {
+				t.Errorf("output contains a line break:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestPrettyFormatterEscapesFreeText(t *testing.T) {
+	out, err := PrettyFormatter{}.Format(injectedEntry())
+	if err != nil {
+		t.Fatalf("Format: %v", err)
+	}
+
+	// The header and the indented detail lines are the only lines
+	for i, line := range bytes.Split(out, []byte("\n")) {
+		if i > 0 && !bytes.HasPrefix(line, []byte("    ")) {
+			t.Errorf("line %d starts a new entry: %q", i+1, line)
+		}
+		if bytes.ContainsRune(line, '\r') {
+			t.Errorf("line %d contains a carriage return: %q", i+1, line)
+		}
+	}
+	for _, want := range []string{`peer\ninfo forged`, `lookup\r\nfatal forged`, `first line\nsecond line`} {
+		if !bytes.Contains(out, []byte(want)) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestLoggedEntryStaysOnOneLine(t *testing.T) {
+	for name, f := range map[string]Formatter{"logfmt": LogfmtFormatter{}, "pretty": PrettyFormatter{}} {
+		t.Run(name, func(t *testing.T) {
+			l, sink := newTestLogger(t, Config{})
+			err := l.Log(SOVDEV_LOGLEVELS.ERROR, "lookup\nforged", "first\r\nsecond", "", nil, nil, errors.New("boom\nforged"), "")
+			if err != nil {
+				t.Fatalf("Log: %v", err)
+			}
+			entries := sink.Entries()
+			out, err := f.Format(entries[len(entries)-1])
+			if err != nil {
+				t.Fatalf("Format: %v", err)
+			}
+			for i, line := range bytes.Split(out, []byte("\n")) {
+				if i > 0 && !bytes.HasPrefix(line, []byte("    ")) {
+					t.Errorf("line %d starts a new entry: %q", i+1, line)
+				}
+			}
+		})
+	}
+}
+
+func TestControlCharactersEscapedOnce(t *testing.T) {
+	l, sink, collector := newCollectorLogger(t, Config{})
+	message := "first line\nsecond line"
+	input := map[string]interface{}{"note": "tab\there"}
+	if err := l.Log(SOVDEV_LOGLEVELS.INFO, "TestEscapedOnce", message, "", input, nil, nil, ""); err != nil {
+		t.Fatalf("Log: %v", err)
+	}
+	if err := l.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	entry := sink.Entries()[len(sink.Entries())-1]
+	if entry.Message != message {
+		t.Errorf("entry message = %q, want the original text", entry.Message)
+	}
+	out, err := JSONFormatter{}.Format(entry)
+	if err != nil {
+		t.Fatalf("Format: %v", err)
+	}
+	for _, want := range []string{`"first line\nsecond line"`, `"tab\there"`} {
+		if !bytes.Contains(out, []byte(want)) {
+			t.Errorf("JSON output does not contain %s:\n%s", want, out)
+		}
+	}
+
+	record := exportedLogRecord(t, collector, message)
+	if record == nil {
+		t.Fatal("log record not exported with the original message")
+	}
+	for _, kv := range record.Attributes {
+		if kv.Key == "input_json" && kv.Value.GetStringValue() != `{"note":"tab\there"}` {
+			t.Errorf("OTLP input_json = %s", kv.Value.GetStringValue())
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package sovdevlogger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// gelfChunkSize keeps UDP datagrams below common MTU-safe limits (Graylog accepts up to 8192)
+	gelfChunkSize = 8154
+	// gelfMaxChunks is the GELF limit on chunks per message
+	gelfMaxChunks = 128
+	// gelfWriteTimeout bounds a TCP write so a stuck Graylog cannot block logging
+	gelfWriteTimeout = 2 * time.Second
+)
+
+// GELFWriter ships entries to Graylog as GELF 1.1 messages over UDP or TCP.
+// UDP messages are gzip-compressed and chunked when large; TCP messages are
+// null-byte delimited. Safe for concurrent use.
+type GELFWriter struct {
+	network string
+	address string
+	host    string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewGELFWriter creates a writer for endpoint, e.g. "udp://graylog:12201" or
+// "tcp://graylog:12201". The connection is opened lazily and re-opened after errors.
+func NewGELFWriter(endpoint string) (*GELFWriter, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GELF endpoint %q: %w", endpoint, err)
+	}
+	if u.Scheme != "udp" && u.Scheme != "tcp" {
+		return nil, fmt.Errorf("invalid GELF endpoint %q: scheme must be udp or tcp", endpoint)
+	}
+	if u.Port() == "" {
+		return nil, fmt.Errorf("invalid GELF endpoint %q: port is required", endpoint)
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	return &GELFWriter{network: u.Scheme, address: u.Host, host: host}, nil
+}
+
+// WriteEntry sends one entry as a GELF message
+func (w *GELFWriter) WriteEntry(level SovdevLogLevel, entry StructuredLogEntry) error {
+	payload, err := json.Marshal(w.message(level, entry))
+	if err != nil {
+		return fmt.Errorf("marshal GELF message: %w", err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		conn, err := net.DialTimeout(w.network, w.address, gelfWriteTimeout)
+		if err != nil {
+			return fmt.Errorf("connect to %s://%s: %w", w.network, w.address, err)
+		}
+		w.conn = conn
+	}
+
+	if w.network == "tcp" {
+		err = w.writeTCP(payload)
+	} else {
+		err = w.writeUDP(payload)
+	}
+	if err != nil {
+		// Drop the connection so the next entry reconnects
+		w.conn.Close()
+		w.conn = nil
+	}
+	return err
+}
+
+// Write sends one entry as a GELF message (Sink)
+func (w *GELFWriter) Write(entry StructuredLogEntry) error {
+	return w.WriteEntry(SovdevLogLevel(entry.Level), entry)
+}
+
+// Close closes the connection to Graylog
+func (w *GELFWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// message maps an entry to GELF 1.1 fields; sovdev fields become "_"-prefixed additional fields
+func (w *GELFWriter) message(level SovdevLogLevel, entry StructuredLogEntry) map[string]interface{} {
+	msg := map[string]interface{}{
+		"version":       "1.1",
+		"host":          w.host,
+		"short_message": entry.Message,
+		"level":         gelfLevel(level),
+	}
+	if ts, err := time.Parse(time.RFC3339Nano, entry.Timestamp); err == nil {
+		msg["timestamp"] = float64(ts.UnixMilli()) / 1000
+	}
+	if entry.ExceptionStacktrace != "" {
+		msg["full_message"] = entry.ExceptionStacktrace
+	}
+
+	fields := map[string]string{
+		"schema_version":     entry.SchemaVersion,
+		"service_name":       entry.ServiceName,
+		"service_version":    entry.ServiceVersion,
+		"session_id":         entry.SessionID,
+		"correlation_id":     entry.CorrelationID,
+		"tenant_id":          entry.TenantID,
+		"peer_service":       entry.PeerService,
+		"function_name":      entry.FunctionName,
+		"trace_id":           entry.TraceID,
+		"span_id":            entry.SpanID,
+		"event_id":           entry.EventID,
+		"log_type":           string(entry.LogType),
+		"caller_file":        entry.CallerFile,
+		"exception_type":     entry.ExceptionType,
+		"exception_code":     entry.ExceptionCode,
+		"exception_message":  entry.ExceptionMessage,
+		"validation_warning": entry.ValidationWarning,
+	}
+	for name, value := range fields {
+		if value != "" {
+			msg["_"+name] = value
+		}
+	}
+	if entry.CallerLine > 0 {
+		msg["_caller_line"] = entry.CallerLine
+	}
+	if entry.Repeated > 0 {
+		msg["_repeated"] = entry.Repeated
+	}
+
+	// Payloads as JSON strings; GELF additional fields must be scalars
+	payloads := map[string]interface{}{
+		"input_json":    entry.InputJSON,
+		"response_json": entry.ResponseJSON,
+		"attributes":    entry.Attributes,
+	}
+	for name, value := range payloads {
+		if value == nil {
+			continue
+		}
+		if data, err := json.Marshal(value); err == nil && string(data) != "null" {
+			msg["_"+name] = string(data)
+		}
+	}
+
+	return msg
+}
+
+// gelfLevel maps a sovdev level to the syslog severity GELF expects,
+// derived from the OTEL severity number used for OTLP
+func gelfLevel(level SovdevLogLevel) int {
+	switch severity := mapToSeverityNumber(level); {
+	case severity >= 21:
+		return 2 // Critical
+	case severity >= 17:
+		return 3 // Error
+	case severity >= 13:
+		return 4 // Warning
+	case severity >= 9:
+		return 6 // Informational
+	default:
+		return 7 // Debug
+	}
+}
+
+func (w *GELFWriter) writeTCP(payload []byte) error {
+	w.conn.SetWriteDeadline(time.Now().Add(gelfWriteTimeout))
+	_, err := w.conn.Write(append(payload, 0))
+	return err
+}
+
+func (w *GELFWriter) writeUDP(payload []byte) error {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(payload)
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("compress GELF message: %w", err)
+	}
+	data := buf.Bytes()
+
+	if len(data) <= gelfChunkSize {
+		_, err := w.conn.Write(data)
+		return err
+	}
+
+	chunks := (len(data) + gelfChunkSize - 1) / gelfChunkSize
+	if chunks > gelfMaxChunks {
+		return fmt.Errorf("GELF message too large (%d bytes compressed)", len(data))
+	}
+
+	// Chunk header: magic bytes, 8-byte message ID, sequence number, sequence count
+	var messageID [8]byte
+	rand.Read(messageID[:])
+	for i := 0; i < chunks; i++ {
+		end := min((i+1)*gelfChunkSize, len(data))
+		chunk := make([]byte, 0, 12+end-i*gelfChunkSize)
+		chunk = append(chunk, 0x1e, 0x0f)
+		chunk = append(chunk, messageID[:]...)
+		chunk = append(chunk, byte(i), byte(chunks))
+		chunk = append(chunk, data[i*gelfChunkSize:end]...)
+		if _, err := w.conn.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
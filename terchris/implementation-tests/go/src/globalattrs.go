@@ -0,0 +1,109 @@
+package sovdevlogger
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// globalAttributes is one immutable snapshot of the attributes set with
+// SetGlobalAttributes, in entry and OpenTelemetry form
+type globalAttributes struct {
+	values map[string]interface{}
+	otel   []attribute.KeyValue
+}
+
+// globalAttributeSet holds the current snapshot; readers never lock
+type globalAttributeSet struct {
+	mu      sync.Mutex // serializes writers
+	current atomic.Pointer[globalAttributes]
+}
+
+// SovdevSetGlobalAttributes adds attributes to everything the default logger
+// emits from now on, e.g. deployment metadata only known after startup:
+//
+//	sovdevlogger.SovdevSetGlobalAttributes(map[string]interface{}{"cloud.region": region, "host.node": node})
+//
+// Keys are merged into the existing set; a nil value removes a key. These are
+// emit-time attributes, not resource attributes (the OpenTelemetry resource is
+// fixed at initialization): they appear in each entry's attributes (an entry's
+// own attributes win), on every span started afterwards, including spans from
+// other tracers of the global provider, and on the sovdev.operations,
+// sovdev.errors and sovdev.operation.duration measurements. Backends show them
+// per record rather than per service, and entries and spans emitted before the
+// call do not have them. Keep the values low-cardinality, as they become metric
+// labels. No-op before SovdevInitialize.
+func SovdevSetGlobalAttributes(attrs map[string]interface{}) {
+	logger := defaultLogger()
+
+	if logger == nil {
+		return
+	}
+	logger.SetGlobalAttributes(attrs)
+}
+
+// SetGlobalAttributes adds emit-time attributes to this logger's entries, spans
+// and operation metrics (see SovdevSetGlobalAttributes)
+func (l *Logger) SetGlobalAttributes(attrs map[string]interface{}) {
+	l.globalAttrs.mu.Lock()
+	defer l.globalAttrs.mu.Unlock()
+
+	values := make(map[string]interface{})
+	if current := l.globalAttrs.current.Load(); current != nil {
+		for k, v := range current.values {
+			values[k] = v
+		}
+	}
+	for k, v := range attrs {
+		if v == nil {
+			delete(values, k)
+		} else {
+			values[k] = v
+		}
+	}
+	l.globalAttrs.current.Store(&globalAttributes{values: values, otel: spanAttributes(values)})
+}
+
+// load returns the current snapshot, or nil when none is set
+func (s *globalAttributeSet) load() *globalAttributes {
+	current := s.current.Load()
+	if current == nil || len(current.values) == 0 {
+		return nil
+	}
+	return current
+}
+
+// withGlobalAttributes returns attributes with the global attributes added
+// under keys it does not have. attributes itself is not modified.
+func (l *Logger) withGlobalAttributes(attributes map[string]interface{}) map[string]interface{} {
+	global := l.globalAttrs.load()
+	if global == nil {
+		return attributes
+	}
+	merged := make(map[string]interface{}, len(global.values)+len(attributes))
+	for k, v := range global.values {
+		merged[k] = v
+	}
+	for k, v := range attributes {
+		merged[k] = v
+	}
+	return merged
+}
+
+// globalAttributeProcessor sets the global attributes on every span when it starts
+type globalAttributeProcessor struct {
+	l *Logger
+}
+
+func (p globalAttributeProcessor) OnStart(_ context.Context, s sdktrace.ReadWriteSpan) {
+	if global := p.l.globalAttrs.load(); global != nil {
+		s.SetAttributes(global.otel...)
+	}
+}
+
+func (p globalAttributeProcessor) OnEnd(sdktrace.ReadOnlySpan)      {}
+func (p globalAttributeProcessor) Shutdown(context.Context) error   { return nil }
+func (p globalAttributeProcessor) ForceFlush(context.Context) error { return nil }
@@ -0,0 +1,119 @@
+package sovdevlogger
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestGlobalAttributesOnEntries(t *testing.T) {
+	l, sink := newTestLogger(t, Config{})
+
+	_ = l.Log(SOVDEV_LOGLEVELS.INFO, "TestGlobalAttributes", "Before", "", nil, nil, nil, "")
+	l.SetGlobalAttributes(map[string]interface{}{"cloud.region": "norwayeast", "host.node": "node-1"})
+	l.SetGlobalAttributes(map[string]interface{}{"host.node": nil, "deployment": "blue"})
+	_ = l.LogAttrs(SOVDEV_LOGLEVELS.INFO, "TestGlobalAttributes", "After", "", nil, nil, nil, "",
+		map[string]interface{}{"deployment": "green", "batch": 7})
+
+	entries := sink.Entries()
+	before, after := entries[len(entries)-2], entries[len(entries)-1]
+	if len(before.Attributes) != 0 {
+		t.Errorf("entry logged before SetGlobalAttributes has attributes %v", before.Attributes)
+	}
+	want := map[string]interface{}{"cloud.region": "norwayeast", "deployment": "green", "batch": 7}
+	if !reflect.DeepEqual(after.Attributes, want) {
+		t.Errorf("attributes = %v, want %v (merged, host.node removed, the entry's own deployment kept)", after.Attributes, want)
+	}
+}
+
+func TestSovdevSetGlobalAttributes(t *testing.T) {
+	testEnv(t)
+	SovdevSetGlobalAttributes(map[string]interface{}{"cloud.region": "ignored"}) // no-op before initialization
+
+	sink := &recordingSink{}
+	cfg := testConfig("1.0.0")
+	cfg.Sinks = []Sink{sink}
+	initTestDefault(t, cfg)
+	SovdevLog(SOVDEV_LOGLEVELS.INFO, "TestGlobalAttributes", "No global attributes", "", nil, nil, nil, "")
+	if attrs := sink.Entries()[len(sink.Entries())-1].Attributes; len(attrs) != 0 {
+		t.Errorf("attributes set before initialization were kept: %v", attrs)
+	}
+
+	SovdevSetGlobalAttributes(map[string]interface{}{"cloud.region": "norwayeast"})
+	SovdevLog(SOVDEV_LOGLEVELS.INFO, "TestGlobalAttributes", "With global attributes", "", nil, nil, nil, "")
+	if got := sink.Entries()[len(sink.Entries())-1].Attributes["cloud.region"]; got != "norwayeast" {
+		t.Errorf("cloud.region = %v, want norwayeast", got)
+	}
+}
+
+func TestGlobalAttributesOnSpansAndMetrics(t *testing.T) {
+	l, _, collector := newCollectorLogger(t, Config{})
+	l.SetGlobalAttributes(map[string]interface{}{"cloud.region": "norwayeast", "node.index": 2})
+
+	_, span := l.tracer.Start(context.Background(), "sovdev span")
+	span.End()
+	_, span = l.traceProvider.Tracer("other").Start(context.Background(), "other span")
+	span.End()
+	_ = l.Log(SOVDEV_LOGLEVELS.ERROR, "TestGlobalAttributes", "Counted", "", nil, nil, nil, "")
+	if err := l.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	spans := exportedSpanAttributes(t, collector)
+	for _, name := range []string{"sovdev span", "other span"} {
+		attrs, ok := spans[name]
+		if !ok {
+			t.Errorf("span %q not exported", name)
+			continue
+		}
+		if attrs["cloud.region"].GetStringValue() != "norwayeast" || attrs["node.index"].GetIntValue() != 2 {
+			t.Errorf("span %q attributes = %v, want the global attributes", name, attrs)
+		}
+	}
+
+	metrics := exportedMetrics(t, collector)
+	for _, name := range []string{"sovdev.operations.total", "sovdev.errors.total"} {
+		points := metrics[name].GetSum().GetDataPoints()
+		if len(points) == 0 {
+			t.Errorf("%s has no data points", name)
+		}
+		for _, dp := range points {
+			found := false
+			for _, kv := range dp.Attributes {
+				found = found || kv.Key == "cloud.region" && kv.Value.GetStringValue() == "norwayeast"
+			}
+			if !found {
+				t.Errorf("%s data point without cloud.region: %v", name, dp.Attributes)
+			}
+		}
+	}
+}
+
+// exportedSpanAttributes decodes every trace export the collector received, as
+// attributes by span name
+func exportedSpanAttributes(t *testing.T, c *testCollector) map[string]map[string]*commonpb.AnyValue {
+	t.Helper()
+	spans := make(map[string]map[string]*commonpb.AnyValue)
+	for _, body := range c.Requests("/v1/traces") {
+		var req coltracepb.ExportTraceServiceRequest
+		if err := proto.Unmarshal(body, &req); err != nil {
+			t.Fatalf("decode trace export: %v", err)
+		}
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				for _, span := range ss.Spans {
+					attrs := make(map[string]*commonpb.AnyValue)
+					for _, kv := range span.Attributes {
+						attrs[kv.Key] = kv.Value
+					}
+					spans[span.Name] = attrs
+				}
+			}
+		}
+	}
+	return spans
+}
@@ -0,0 +1,86 @@
+package sovdevlogger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// healthCheckTimeout bounds HealthCheck when ctx has no earlier deadline
+const healthCheckTimeout = 2 * time.Second
+
+// otlpTarget is the endpoint of one OTLP exporter
+type otlpTarget struct {
+	signal string
+	url    string
+	client *http.Client // the exporter's client (Host header, bearer token); nil for the default
+}
+
+// SovdevHealthCheck reports whether the OTLP endpoints of the default logger
+// accept exports (see HealthCheck). It returns an error before SovdevInitialize.
+//
+// A failing check means telemetry is degraded (entries are queued, retried and
+// eventually dropped; files and console still get them), not that the
+// application is unhealthy. Use it for a dedicated probe or a status page; wired
+// into a readiness or liveness probe it would take the service down whenever
+// the collector is.
+func SovdevHealthCheck(ctx context.Context) error {
+	logger := defaultLogger()
+
+	if logger == nil {
+		return fmt.Errorf("sovdev-logger not initialized")
+	}
+	return logger.HealthCheck(ctx)
+}
+
+// HealthCheck sends an empty export (no spans, records or metrics) to each
+// configured OTLP endpoint, in parallel, with the exporters' Host header and
+// bearer token. Any endpoint that is unreachable or answers with a 4xx/5xx
+// status is reported in the joined error. The check takes at most 2s (or until
+// ctx is done), is not counted in Stats and does not touch the export queues,
+// so it is cheap enough to run on every probe.
+func (l *Logger) HealthCheck(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	errs := make([]error, len(l.otlpTargets))
+	var wg sync.WaitGroup
+	for i, target := range l.otlpTargets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = target.check(ctx)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// check posts an empty protobuf export request, which a collector accepts with 200
+func (t otlpTarget) check(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, http.NoBody)
+	if err != nil {
+		return fmt.Errorf("OTLP %s endpoint %s: %w", t.signal, t.url, err)
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+
+	client := t.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("OTLP %s endpoint %s unreachable: %w", t.signal, t.url, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("OTLP %s endpoint %s returned %s", t.signal, t.url, resp.Status)
+	}
+	return nil
+}
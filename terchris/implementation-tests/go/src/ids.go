@@ -0,0 +1,66 @@
+package sovdevlogger
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync/atomic"
+)
+
+// IDGenerator creates the IDs the logger writes as session_id and event_id and
+// uses for a generated service.instance.id. IDs must be lowercase UUID v4
+// strings like "0b3f8a5e-7c1d-4e2f-9a6b-5c4d3e2f1a0b", the format the log entry
+// schema requires. Must be safe for concurrent use.
+type IDGenerator interface {
+	NewID() string
+}
+
+// RandomIDGenerator is the default IDGenerator: random UUID v4 read from Reader,
+// or from crypto/rand when Reader is nil. A seeded math/rand source makes IDs
+// reproducible in tests (wrap it in a lock if several goroutines log).
+type RandomIDGenerator struct {
+	Reader io.Reader
+}
+
+// randomIDFallbackReported is set once a failing Reader has been reported
+var randomIDFallbackReported atomic.Bool
+
+// NewID returns a random UUID v4. When Reader fails or runs short, the ID is
+// read from crypto/rand instead (reported once per process); it only panics if
+// crypto/rand fails too.
+func (g RandomIDGenerator) NewID() string {
+	var b [16]byte
+	if g.Reader != nil {
+		_, err := io.ReadFull(g.Reader, b[:])
+		if err == nil {
+			return formatUUIDv4(b)
+		}
+		if randomIDFallbackReported.CompareAndSwap(false, true) {
+			fmt.Printf("⚠️  RandomIDGenerator.Reader failed, using crypto/rand: %v\n", err)
+		}
+	}
+	if _, err := io.ReadFull(rand.Reader, b[:]); err != nil {
+		panic("sovdev-logger: reading random ID: " + err.Error())
+	}
+	return formatUUIDv4(b)
+}
+
+// formatUUIDv4 sets the version and variant bits in b and formats it as a
+// lowercase UUID string
+func formatUUIDv4(b [16]byte) string {
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+
+	var s [36]byte
+	hex.Encode(s[0:8], b[0:4])
+	s[8] = '-'
+	hex.Encode(s[9:13], b[4:6])
+	s[13] = '-'
+	hex.Encode(s[14:18], b[6:8])
+	s[18] = '-'
+	hex.Encode(s[19:23], b[8:10])
+	s[23] = '-'
+	hex.Encode(s[24:], b[10:])
+	return string(s[:])
+}
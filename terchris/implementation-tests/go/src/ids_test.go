@@ -0,0 +1,50 @@
+package sovdevlogger
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+var uuidV4Pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestRandomIDGeneratorUsesReader(t *testing.T) {
+	seed := bytes.Repeat([]byte{0xab}, 32)
+	first := RandomIDGenerator{Reader: bytes.NewReader(seed)}.NewID()
+	second := RandomIDGenerator{Reader: bytes.NewReader(seed)}.NewID()
+	if first != second || !uuidV4Pattern.MatchString(first) {
+		t.Errorf("IDs from the same reader = %q, %q, want the same UUID v4", first, second)
+	}
+}
+
+func TestRandomIDGeneratorFallsBack(t *testing.T) {
+	randomIDFallbackReported.Store(false)
+	t.Cleanup(func() { randomIDFallbackReported.Store(false) })
+	stdout := captureFile(t, &os.Stdout)
+
+	readers := []*bytes.Reader{bytes.NewReader([]byte("short")), bytes.NewReader(nil)}
+	ids := make(map[string]bool)
+	for _, r := range readers {
+		id := RandomIDGenerator{Reader: r}.NewID()
+		if !uuidV4Pattern.MatchString(id) {
+			t.Errorf("ID from a short reader = %q, want a UUID v4", id)
+		}
+		ids[id] = true
+	}
+	failing := RandomIDGenerator{Reader: iotest.ErrReader(errors.New("entropy exhausted"))}.NewID()
+	if !uuidV4Pattern.MatchString(failing) {
+		t.Errorf("ID from a failing reader = %q, want a UUID v4", failing)
+	}
+	ids[failing] = true
+	if len(ids) != 3 {
+		t.Errorf("fallback IDs are not random: %v", ids)
+	}
+
+	if got := strings.Count(stdout(), "using crypto/rand"); got != 1 {
+		t.Errorf("fallback reported %d times, want once", got)
+	}
+}
@@ -0,0 +1,265 @@
+package sovdevlogger
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// JobStatus represents the lifecycle state of a batch job
+type JobStatus string
+
+// SOVDEV_JOBSTATUS defines the standard job statuses
+// Use these instead of freeform strings so dashboards can rely on the values
+var SOVDEV_JOBSTATUS = struct {
+	STARTED   JobStatus
+	RUNNING   JobStatus
+	COMPLETED JobStatus
+	FAILED    JobStatus
+}{
+	STARTED:   "Started",
+	RUNNING:   "Running",
+	COMPLETED: "Completed",
+	FAILED:    "Failed",
+}
+
+// maxTrackedJobs bounds job start times kept for jobs that never report completion
+const maxTrackedJobs = 1000
+
+// jobTimer remembers when each job (by name) was Started, so the Completed or
+// Failed status can carry the elapsed time
+type jobTimer struct {
+	mu     sync.Mutex
+	starts map[string]time.Time
+}
+
+func (t *jobTimer) start(jobName string, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.starts == nil {
+		t.starts = make(map[string]time.Time)
+	}
+	if _, ok := t.starts[jobName]; !ok && len(t.starts) >= maxTrackedJobs {
+		return
+	}
+	t.starts[jobName] = at
+}
+
+// finish returns the time since jobName was started and forgets it; false when
+// no start was recorded (e.g. Completed without Started, or after a restart)
+func (t *jobTimer) finish(jobName string, at time.Time) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	started, ok := t.starts[jobName]
+	if !ok {
+		return 0, false
+	}
+	delete(t.starts, jobName)
+	return at.Sub(started), true
+}
+
+// JobResult describes the outcome (or current state) of a batch job
+type JobResult struct {
+	Status    JobStatus
+	Total     int
+	Succeeded int
+	Failed    int
+	Duration  time.Duration
+}
+
+// SovdevLogJobResult logs a typed job status entry and records job metrics
+func SovdevLogJobResult(functionName, jobName string, r JobResult) {
+	logger := defaultLogger()
+	if logger == nil {
+		bufferPreInit(func(ctx context.Context, l *Logger) error {
+			l.logJobResult(ctx, functionName, jobName, r)
+			return nil
+		})
+		return
+	}
+
+	logger.LogJobResult(functionName, jobName, r)
+}
+
+// LogJobResult logs a typed job status entry and records job metrics
+//
+// The entry uses log_type "job.status" with consistent input_json keys:
+// job_name, job_status, total_items, succeeded, failed, plus success_rate
+// (percentage) for COMPLETED/FAILED results with Total > 0 and duration_ms
+// when Duration > 0. A zero Duration on COMPLETED/FAILED is filled in from the
+// matching STARTED result of the same job name, if there was one.
+// A FAILED status is logged at ERROR level, everything else at INFO.
+func (l *Logger) LogJobResult(functionName, jobName string, r JobResult) {
+	l.logJobResult(context.Background(), functionName, jobName, r)
+}
+
+func (l *Logger) logJobResult(ctx context.Context, functionName, jobName string, r JobResult) {
+	level := SOVDEV_LOGLEVELS.INFO
+	if r.Status == SOVDEV_JOBSTATUS.FAILED {
+		level = SOVDEV_LOGLEVELS.ERROR
+	}
+	l.logJob(ctx, level, functionName, jobName, r, nil)
+}
+
+// logJob writes the job.status entry for r, with extra input_json keys, and
+// records the job metrics
+func (l *Logger) logJob(ctx context.Context, level SovdevLogLevel, functionName, jobName string, r JobResult, extra map[string]interface{}) {
+	switch r.Status {
+	case SOVDEV_JOBSTATUS.STARTED:
+		l.jobTimer.start(jobName, entryTime(ctx))
+	case SOVDEV_JOBSTATUS.COMPLETED, SOVDEV_JOBSTATUS.FAILED:
+		if elapsed, ok := l.jobTimer.finish(jobName, entryTime(ctx)); ok && r.Duration == 0 {
+			r.Duration = elapsed
+		}
+	}
+
+	input := map[string]interface{}{
+		"job_name":    jobName,
+		"job_status":  string(r.Status),
+		"total_items": r.Total,
+		"succeeded":   r.Succeeded,
+		"failed":      r.Failed,
+	}
+	finished := r.Status == SOVDEV_JOBSTATUS.COMPLETED || r.Status == SOVDEV_JOBSTATUS.FAILED
+	if finished && r.Total > 0 {
+		input["success_rate"] = (r.Succeeded * 100) / r.Total
+	}
+	durationMs := float64(r.Duration) / float64(time.Millisecond)
+	if r.Duration > 0 {
+		input["duration_ms"] = durationMs
+	}
+	for k, v := range extra {
+		input[k] = v
+	}
+
+	message := fmt.Sprintf("Job %s: %s", r.Status, jobName)
+	l.log(ctx, level, functionName, message, l.selfPeerName, input, nil, nil, "", SOVDEV_LOGTYPES.JOB_STATUS)
+
+	if l.jobCounter != nil {
+		attrs := metric.WithAttributes(
+			attribute.String("job_name", jobName),
+			attribute.String("job_status", string(r.Status)),
+		)
+		l.jobCounter.Add(ctx, 1, attrs)
+		if r.Duration > 0 {
+			l.jobDuration.Record(ctx, durationMs, attrs)
+		}
+	}
+}
+
+// maxJobSummaryFailures caps the failed items listed in a job summary entry
+const maxJobSummaryFailures = 20
+
+// JobItemFailure is one failed item of a batch job
+type JobItemFailure struct {
+	ItemID string
+	Err    error
+}
+
+// JobSummary describes a finished batch job, including which items failed
+type JobSummary struct {
+	JobResult
+	// FailedItems lists failed items; the first 20 are logged
+	FailedItems []JobItemFailure
+}
+
+// SovdevLogJobSummary logs the outcome of a batch job that may have partially
+// failed as one job.status entry (see LogJobSummary)
+func SovdevLogJobSummary(functionName, jobName string, summary JobSummary) {
+	logger := defaultLogger()
+	if logger == nil {
+		bufferPreInit(func(ctx context.Context, l *Logger) error {
+			l.logJobSummary(ctx, functionName, jobName, summary)
+			return nil
+		})
+		return
+	}
+
+	logger.LogJobSummary(functionName, jobName, summary)
+}
+
+// LogJobSummary is LogJobResult for a finished job, adding failed_items to
+// input_json: up to 20 {"item_id", "error"} objects (error messages redacted like
+// exception_message), with failed_items_omitted counting the rest. An empty
+// Status becomes FAILED when no item succeeded and COMPLETED otherwise. The entry
+// is logged at ERROR for FAILED, WARN for a job with failed items and INFO otherwise.
+func (l *Logger) LogJobSummary(functionName, jobName string, summary JobSummary) {
+	l.logJobSummary(context.Background(), functionName, jobName, summary)
+}
+
+func (l *Logger) logJobSummary(ctx context.Context, functionName, jobName string, summary JobSummary) {
+	r := summary.JobResult
+	if r.Status == "" {
+		r.Status = SOVDEV_JOBSTATUS.COMPLETED
+		if r.Failed > 0 && r.Succeeded == 0 {
+			r.Status = SOVDEV_JOBSTATUS.FAILED
+		}
+	}
+
+	level := SOVDEV_LOGLEVELS.INFO
+	switch {
+	case r.Status == SOVDEV_JOBSTATUS.FAILED:
+		level = SOVDEV_LOGLEVELS.ERROR
+	case r.Failed > 0 || len(summary.FailedItems) > 0:
+		level = SOVDEV_LOGLEVELS.WARN
+	}
+
+	var extra map[string]interface{}
+	if len(summary.FailedItems) > 0 {
+		listed := summary.FailedItems
+		if len(listed) > maxJobSummaryFailures {
+			listed = listed[:maxJobSummaryFailures]
+		}
+		items := make([]interface{}, 0, len(listed))
+		for _, failure := range listed {
+			item := map[string]interface{}{"item_id": failure.ItemID}
+			if failure.Err != nil {
+				item["error"] = removeCredentials(failure.Err.Error())
+			}
+			items = append(items, item)
+		}
+		extra = map[string]interface{}{"failed_items": items}
+		if omitted := len(summary.FailedItems) - len(listed); omitted > 0 {
+			extra["failed_items_omitted"] = omitted
+		}
+	}
+
+	l.logJob(ctx, level, functionName, jobName, r, extra)
+}
+
+// shouldLogProgress reports whether LogJobProgress writes an entry for item current
+// of total under Config.JobProgressEvery / ThrottleJobProgress. It is stateless, so
+// it assumes current advances one item at a time as in the batch example; the first
+// and last item are always written.
+func (l *Logger) shouldLogProgress(current, total int) bool {
+	if current <= 1 || total <= 0 || current >= total {
+		return true
+	}
+	if every := l.config.JobProgressEvery; every > 0 {
+		return current%every == 0
+	}
+	if l.config.ThrottleJobProgress {
+		// Integer percentage changed since the previous item
+		return current*100/total != (current-1)*100/total
+	}
+	return true
+}
+
+// jobStatusDuration tracks Started/Completed/Failed statuses logged with
+// LogJobStatus and returns the job duration for a finished job, if known
+func (l *Logger) jobStatusDuration(ctx context.Context, jobName, status string) (time.Duration, bool) {
+	switch {
+	case strings.EqualFold(status, string(SOVDEV_JOBSTATUS.STARTED)):
+		l.jobTimer.start(jobName, entryTime(ctx))
+	case strings.EqualFold(status, string(SOVDEV_JOBSTATUS.COMPLETED)), strings.EqualFold(status, string(SOVDEV_JOBSTATUS.FAILED)):
+		return l.jobTimer.finish(jobName, entryTime(ctx))
+	}
+	return 0, false
+}
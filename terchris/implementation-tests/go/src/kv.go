@@ -0,0 +1,74 @@
+package sovdevlogger
+
+import (
+	"context"
+	"fmt"
+)
+
+// SovdevKV logs a transaction entry whose input_json is built from alternating
+// key/value pairs, like zap's SugaredLogger or logr:
+//
+//	sovdevlogger.SovdevKV(sovdevlogger.SOVDEV_LOGLEVELS.INFO, "importFile", "File imported",
+//	    "file_name", name, "rows", rows)
+//
+// Keys should be strings (others are formatted with fmt.Sprint); a repeated key
+// keeps its last value and error values are logged as their message. An odd
+// number of arguments does not panic: the key without a value is dropped and
+// the entry gets an attributes.kv_error describing it.
+func SovdevKV(level SovdevLogLevel, functionName, message string, kv ...interface{}) {
+	logger := defaultLogger()
+	if logger == nil {
+		bufferPreInit(func(ctx context.Context, l *Logger) error {
+			return l.logKV(ctx, level, functionName, message, kv)
+		})
+		return
+	}
+
+	if err := logger.KV(level, functionName, message, kv...); err != nil {
+		fmt.Printf("⚠️  Log entry rejected: %v\n", err)
+	}
+}
+
+// KV logs a transaction entry with input_json built from key/value pairs (see SovdevKV)
+func (l *Logger) KV(level SovdevLogLevel, functionName, message string, kv ...interface{}) error {
+	return l.logKV(context.Background(), level, functionName, message, kv)
+}
+
+func (l *Logger) logKV(ctx context.Context, level SovdevLogLevel, functionName, message string, kv []interface{}) error {
+	pairs, kvErr := kvInput(kv)
+	var input interface{}
+	if pairs != nil {
+		input = pairs
+	}
+	var attributes map[string]interface{}
+	if kvErr != "" {
+		attributes = map[string]interface{}{"kv_error": kvErr}
+	}
+	return l.logAttrs(ctx, level, functionName, message, "", input, nil, nil, "", SOVDEV_LOGTYPES.TRANSACTION, attributes)
+}
+
+// kvInput folds key/value pairs into an input_json map (nil for none) and
+// describes a dangling key, if any
+func kvInput(kv []interface{}) (map[string]interface{}, string) {
+	if len(kv) == 0 {
+		return nil, ""
+	}
+
+	input := make(map[string]interface{}, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		key, ok := kv[i].(string)
+		if !ok {
+			key = fmt.Sprint(kv[i])
+		}
+		value := kv[i+1]
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		input[key] = value
+	}
+
+	if len(kv)%2 != 0 {
+		return input, fmt.Sprintf("odd number of key/value arguments (%d); key %q has no value", len(kv), fmt.Sprint(kv[len(kv)-1]))
+	}
+	return input, ""
+}
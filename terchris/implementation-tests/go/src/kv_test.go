@@ -0,0 +1,58 @@
+package sovdevlogger
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestKVInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		kv      []interface{}
+		want    map[string]interface{}
+		wantErr string
+	}{
+		{"none", nil, nil, ""},
+		{"pairs", []interface{}{"file_name", "a.csv", "rows", 12}, map[string]interface{}{"file_name": "a.csv", "rows": 12}, ""},
+		{"repeated key keeps the last value", []interface{}{"rows", 1, "rows", 2}, map[string]interface{}{"rows": 2}, ""},
+		{"non-string key", []interface{}{42, "answer"}, map[string]interface{}{"42": "answer"}, ""},
+		{"error value", []interface{}{"err", errors.New("boom")}, map[string]interface{}{"err": "boom"}, ""},
+		{"odd arity", []interface{}{"file_name", "a.csv", "rows"}, map[string]interface{}{"file_name": "a.csv"}, `key "rows" has no value`},
+		{"single key", []interface{}{"rows"}, map[string]interface{}{}, `odd number of key/value arguments (1)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, kvErr := kvInput(tt.kv)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("input = %#v, want %#v", got, tt.want)
+			}
+			if (tt.wantErr == "") != (kvErr == "") || !strings.Contains(kvErr, tt.wantErr) {
+				t.Errorf("kv_error = %q, want it to contain %q", kvErr, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSovdevKVOddArity(t *testing.T) {
+	sink := &recordingSink{}
+	cfg := testConfig("1.0.0")
+	cfg.Sinks = []Sink{sink}
+	initTestDefault(t, cfg)
+
+	SovdevKV(SOVDEV_LOGLEVELS.INFO, "importFile", "File imported", "file_name", "a.csv", "rows")
+
+	entries := sink.Entries()
+	entry := entries[len(entries)-1]
+	if entry.Message != "File imported" || entry.LogType != SOVDEV_LOGTYPES.TRANSACTION {
+		t.Fatalf("last entry = %q (%s), want the KV entry", entry.Message, entry.LogType)
+	}
+	if input, ok := entry.InputJSON.(json.RawMessage); !ok || string(input) != `{"file_name":"a.csv"}` {
+		t.Errorf("input_json = %s, want the complete pair only", entry.InputJSON)
+	}
+	if kvErr, _ := entry.Attributes["kv_error"].(string); !strings.Contains(kvErr, `"rows"`) {
+		t.Errorf("attributes.kv_error = %q, want the dangling key described", kvErr)
+	}
+}
@@ -2,24 +2,31 @@ package sovdevlogger
 
 import (
 	"context"
+	"crypto/rand"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"io"
 	"net/http"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/google/uuid"
 	"gopkg.in/natefinch/lumberjack.v2"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	otelprom "go.opentelemetry.io/otel/exporters/prometheus"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	sdklog "go.opentelemetry.io/otel/sdk/log"
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/exemplar"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
@@ -27,98 +34,322 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 	otlog "go.opentelemetry.io/otel/log"
 	"go.opentelemetry.io/otel/metric"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
 	apitrace "go.opentelemetry.io/otel/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
 )
 
+// LogSchemaVersion identifies the revision of the log contract that produced an entry.
+// Bump it when field semantics change so ingestion pipelines can branch on it.
+const LogSchemaVersion = "loggeloven-2025"
+
 // StructuredLogEntry represents a complete log entry compliant with "Loggeloven av 2025"
 type StructuredLogEntry struct {
 	Timestamp          string                 `json:"timestamp"`
+	SchemaVersion      string                 `json:"schema_version"`
 	Level              string                 `json:"level,omitempty"`
 	ServiceName        string                 `json:"service_name"`
 	ServiceVersion     string                 `json:"service_version"`
 	SessionID          string                 `json:"session_id"`
+	CorrelationID      string                 `json:"correlation_id,omitempty"`
+	TenantID           string                 `json:"tenant_id,omitempty"`
 	PeerService        string                 `json:"peer_service"`
 	FunctionName       string                 `json:"function_name"`
 	Message            string                 `json:"message"`
 	TraceID            string                 `json:"trace_id"`
 	SpanID             string                 `json:"span_id,omitempty"`
 	EventID            string                 `json:"event_id"`
-	LogType            string                 `json:"log_type"`
+	CallerFile         string                 `json:"caller_file,omitempty"`
+	CallerLine         int                    `json:"caller_line,omitempty"`
+	LogType            SovdevLogType          `json:"log_type"`
 	InputJSON          interface{}            `json:"input_json,omitempty"`
 	ResponseJSON       interface{}            `json:"response_json,omitempty"`
+	Attributes         map[string]interface{} `json:"attributes,omitempty"`
 	ExceptionType      string                 `json:"exception_type,omitempty"`
+	ExceptionCode      string                 `json:"exception_code,omitempty"`
 	ExceptionMessage   string                 `json:"exception_message,omitempty"`
 	ExceptionStacktrace string                `json:"exception_stacktrace,omitempty"`
+	ExceptionChain     []ExceptionLink        `json:"exception_chain,omitempty"`
+	ValidationWarning  string                 `json:"validation_warning,omitempty"`
+	Repeated           int                    `json:"repeated,omitempty"`
+	PrevHash           string                 `json:"prev_hash,omitempty"`
+	EntryHash          string                 `json:"entry_hash,omitempty"`
 }
 
-// Global logger instance
+// defaultDurationBuckets are the sovdev.operation.duration bucket boundaries in
+// milliseconds, from sub-millisecond log emits up to 10s web requests
+var defaultDurationBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+// Default logger instance used by the package-level Sovdev* functions
 var (
-	globalLogger       *sovdevLogger
-	globalMutex        sync.RWMutex
-	globalSessionID    string
-	globalTracer       trace.Tracer
-	globalMeter        metric.Meter
-	globalLogProvider  *sdklog.LoggerProvider
-	globalTraceProvider *sdktrace.TracerProvider
-	globalMeterProvider *sdkmetric.MeterProvider
+	globalLogger *Logger
+	globalMutex  sync.RWMutex
 
-	// Metrics
-	operationCounter   metric.Int64Counter
-	errorCounter       metric.Int64Counter
-	operationDuration  metric.Float64Histogram
-	activeOperations   metric.Int64UpDownCounter
+	// initialized and initConfig guard against repeated SovdevInitialize calls
+	initialized bool
+	initConfig  Config
 )
 
-// sovdevLogger is the internal logger implementation
-type sovdevLogger struct {
+// defaultLogger returns the default logger, or nil before SovdevInitialize.
+// Package-level functions read globalLogger only through it, since
+// SovdevReconfigure and SovdevReset replace it concurrently.
+func defaultLogger() *Logger {
+	globalMutex.RLock()
+	defer globalMutex.RUnlock()
+	return globalLogger
+}
+
+// Logger is a sovdev-logger instance with its own configuration, session and
+// OpenTelemetry pipeline. Most services use the package-level Sovdev* functions,
+// which delegate to the default Logger created by SovdevInitialize. Libraries and
+// multi-tenant code can create independent instances with NewLogger.
+type Logger struct {
 	serviceName       string
 	serviceVersion    string
 	sessionID         string
+	instanceID        string
+	ids               IDGenerator
+	selfPeerName      string       // friendly name resolving to the service itself (Config.SelfPeerName)
+	peerMu            sync.RWMutex // guards peerServiceMap and peerSystemIDs (see AddPeerService)
 	peerServiceMap    map[string]string
-	fileLogger        *log.Logger
-	errorLogger       *log.Logger
-	consoleLogger     *log.Logger
+	peerSystemIDs     map[string]bool // mapped system IDs, for BoundMetricLabels
+	boundMetricLabels bool
+	includeErrorChain bool
+	otlpMapBody       bool
+	pii               *piiRedactor
+	sinks             []namedSink
+	writeMu           sync.Mutex // orders entries across sinks (see writeToOutputs)
 	otlpLogger        otlog.Logger
-	logToConsole      bool
-	logToFile         bool
+	config            Config
+	truncationReported atomic.Bool
+	truncationPending  atomic.Bool // set under writeMu, reported once it is released
+	minSeverity       atomic.Int32
+	defaultSeverity   atomic.Int32 // level for entries logged with an empty or invalid level
+	invalidLevelReported atomic.Bool
+	debugOverride     debugOverride
+	jobTimer          jobTimer
+	shutdownOnce      sync.Once
+	shutdownErr       error
+	selfLogThrottle   *selfLogThrottle
+	dedup             *deduplicator
+	flushOnSeverity   int32
+	periodicFlush     *periodicFlusher
+	otelInternal      *otelInternalQueue // SDK diagnostics worker, default logger only
+	lastErrorFlush    atomic.Int64
+	recent            *recentBuffer
+	stats             *pipelineStats
+	globalAttrs       globalAttributeSet // emit-time attributes (SetGlobalAttributes)
+
+	// OpenTelemetry pipeline
+	tracer            trace.Tracer
+	meter             metric.Meter
+	logProvider       *sdklog.LoggerProvider
+	traceProvider     *sdktrace.TracerProvider
+	meterProvider     *sdkmetric.MeterProvider
+	metricsHandler    http.Handler
+	otlpTargets       []otlpTarget // exporter endpoints probed by HealthCheck
+
+	// Metrics
+	operationCounter  metric.Int64Counter
+	errorCounter      metric.Int64Counter
+	operationDuration metric.Float64Histogram
+	activeOperations  metric.Int64UpDownCounter
+	jobCounter        metric.Int64Counter
+	jobDuration       metric.Float64Histogram
+	exportFailures    metric.Int64Counter
 }
 
 // SovdevInitialize initializes the sovdev-logger with service information
 func SovdevInitialize(serviceName string, serviceVersion string, peerServices map[string]string) error {
+	return SovdevInitializeWithConfig(Config{
+		ServiceName:    serviceName,
+		ServiceVersion: serviceVersion,
+		PeerServices:   peerServices,
+	})
+}
+
+// SovdevInitializeWithConfig initializes the default sovdev-logger with the given configuration
+// and registers its providers as the global OpenTelemetry tracer and meter providers
+//
+// Initialization is idempotent: calling it again with an identical configuration is
+// a no-op, while a different configuration returns an error (use SovdevReconfigure).
+func SovdevInitializeWithConfig(cfg Config) error {
+	globalMutex.Lock()
+	defer globalMutex.Unlock()
+
+	if initialized {
+		if sameConfig(cfg, initConfig) {
+			return nil
+		}
+		return fmt.Errorf("sovdev-logger already initialized with a different configuration; call SovdevReconfigure to change it")
+	}
+
+	return initializeGlobal(cfg)
+}
+
+// SovdevReconfigure replaces the default logger with one built from cfg.
+// Pending telemetry of the previous logger is flushed and its providers shut down.
+func SovdevReconfigure(cfg Config) error {
 	globalMutex.Lock()
 	defer globalMutex.Unlock()
 
+	previous := globalLogger
+	if err := initializeGlobal(cfg); err != nil {
+		return err
+	}
+
+	if previous != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), defaultFlushTimeout)
+		defer cancel()
+		if err := previous.Shutdown(ctx); err != nil {
+			fmt.Printf("⚠️  Previous logger shutdown warning: %v\n", err)
+		}
+	}
+
+	return nil
+}
+
+// initializeGlobal creates the default logger and registers its OTEL providers.
+// Callers must hold globalMutex.
+func initializeGlobal(cfg Config) error {
+	logger, err := NewLogger(cfg)
+	if err != nil {
+		return err
+	}
+
+	registerOTELProviders(logger)
+	installOTELInternalLogging(logger, cfg.OTELLogLevel)
+	globalLogger = logger
+	initialized = true
+	initConfig = cfg
+
+	replayPreInit(logger)
+
+	return nil
+}
+
+// registerOTELProviders makes logger's providers the OTEL globals. A provider
+// that was not created (OpenTelemetry initialization failed) is registered as a
+// no-op one: a typed-nil SDK provider would make otel.Tracer(...).Start panic.
+func registerOTELProviders(logger *Logger) {
+	if logger.traceProvider != nil {
+		otel.SetTracerProvider(logger.traceProvider)
+	} else {
+		otel.SetTracerProvider(tracenoop.NewTracerProvider())
+	}
+	if logger.meterProvider != nil {
+		otel.SetMeterProvider(logger.meterProvider)
+	} else {
+		otel.SetMeterProvider(metricnoop.NewMeterProvider())
+	}
+}
+
+// NewLogger creates an independent Logger. Unlike SovdevInitializeWithConfig it
+// does not touch the global OpenTelemetry providers or the default instance,
+// so several differently-configured loggers can coexist in one process.
+func NewLogger(cfg Config) (*Logger, error) {
+	serviceName := cfg.ServiceName
+	serviceVersion := cfg.ServiceVersion
+	peerServices := cfg.PeerServices
+
 	if serviceName == "" {
-		return fmt.Errorf("service_name is required")
+		return nil, fmt.Errorf("service_name is required")
 	}
 
+	pii, err := newPIIRedactor(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Without an explicit version, use the one the binary was built from
 	if serviceVersion == "" {
-		serviceVersion = "1.0.0"
+		serviceVersion, _ = buildVersion()
 	}
+	cfg.ServiceVersion = serviceVersion
 
-	// Generate session ID
-	globalSessionID = uuid.New().String()
-	fmt.Printf("🔑 Session ID: %s\n", globalSessionID)
+	// Session ID: shared by all workers of a batch when the coordinator passes one
+	// in, otherwise unique to this process
+	sessionID := cfg.SessionID
+	if sessionID == "" {
+		sessionID = os.Getenv("SOVDEV_SESSION_ID")
+	}
+	if sessionID != "" && !isValidSessionID(sessionID) {
+		fmt.Printf("⚠️  Invalid session ID %q, generating a new one\n", sessionID)
+		sessionID = ""
+	}
+	ids := cfg.IDGenerator
+	if ids == nil {
+		ids = RandomIDGenerator{}
+	}
+	if sessionID == "" {
+		sessionID = ids.NewID()
+		if !isValidSessionID(sessionID) {
+			fmt.Printf("⚠️  IDGenerator returned %q, not a lowercase UUID v4; using random IDs\n", sessionID)
+			ids = RandomIDGenerator{}
+			sessionID = ids.NewID()
+		}
+	}
 
-	// Add INTERNAL peer service
+	// The self-reference name (INTERNAL by default) is reserved and always
+	// resolves to the service name
+	selfPeerName := cfg.SelfPeerName
+	if selfPeerName == "" {
+		selfPeerName = getEnv("SOVDEV_SELF_PEER_NAME", peerServiceInternal)
+	}
 	effectivePeerServices := make(map[string]string)
 	for k, v := range peerServices {
+		if k == selfPeerName {
+			fmt.Printf("⚠️  Ignoring peer service mapping for reserved name %s (%q); it always resolves to the service name\n", selfPeerName, v)
+			continue
+		}
 		effectivePeerServices[k] = v
 	}
-	effectivePeerServices["INTERNAL"] = serviceName
+
+	l := &Logger{
+		serviceName:     serviceName,
+		serviceVersion:  serviceVersion,
+		selfPeerName:    selfPeerName,
+		ids:             ids,
+		sessionID:       sessionID,
+		peerServiceMap:  effectivePeerServices,
+		peerSystemIDs:   peerSystemIDs(effectivePeerServices),
+		pii:             pii,
+		config:          cfg,
+		selfLogThrottle: newSelfLogThrottle(selfLogInterval),
+		stats:           newPipelineStats(),
+	}
+
+	if cfg.DedupWindow > 0 {
+		l.dedup = newDeduplicator(cfg.DedupWindow, func(level SovdevLogLevel, entry StructuredLogEntry) {
+			l.writeToOutputs(context.Background(), level, entry)
+		})
+	}
+	l.boundMetricLabels = cfg.BoundMetricLabels || getEnvBool("SOVDEV_BOUND_METRIC_LABELS", false)
+	l.includeErrorChain = cfg.IncludeErrorChain || getEnvBool("SOVDEV_INCLUDE_ERROR_CHAIN", false)
+	l.otlpMapBody = cfg.OTLPMapBody || getEnvBool("SOVDEV_OTLP_MAP_BODY", false)
+	if cfg.RecentLogsSize > 0 {
+		l.recent = newRecentBuffer(cfg.RecentLogsSize)
+	}
 
 	// Initialize OpenTelemetry
-	if err := initializeOpenTelemetry(serviceName, serviceVersion, globalSessionID); err != nil {
+	if err := l.initializeOpenTelemetry(cfg); err != nil {
 		fmt.Printf("⚠️  OpenTelemetry initialization warning: %v\n", err)
 	}
 
-	// Create file loggers
-	logToFile := os.Getenv("LOG_TO_FILE") != "false"
-	logToConsole := os.Getenv("LOG_TO_CONSOLE") != "false"
-
-	var fileLogger, errorLogger, consoleLogger *log.Logger
+	// Built-in outputs, written in this order
+	logToFile := getEnvBool("LOG_TO_FILE", true)
+	logToConsole := getEnvBool("LOG_TO_CONSOLE", true)
+	syncEachWrite := cfg.FileSyncEachWrite || getEnvBool("SOVDEV_FILE_SYNC_EACH_WRITE", false)
+	fileMinSeverity := sinkMinSeverity(cfg.FileMinLevel, "SOVDEV_FILE_MIN_LEVEL", "file")
+	consoleMinSeverity := sinkMinSeverity(cfg.ConsoleMinLevel, "SOVDEV_CONSOLE_MIN_LEVEL", "console")
+	otlpMinSeverity := sinkMinSeverity(cfg.OTLPMinLevel, "SOVDEV_OTLP_MIN_LEVEL", "OTLP")
 
 	if logToFile {
+		fileFormatter := cfg.FileFormatter
+		if fileFormatter == nil {
+			fileFormatter = formatterFromEnv(os.Getenv("SOVDEV_FILE_FORMAT"))
+		}
+
 		logPath := os.Getenv("LOG_FILE_PATH")
 		if logPath == "" {
 			logPath = "./logs/dev.log"
@@ -132,56 +363,204 @@ func SovdevInitialize(serviceName string, serviceVersion string, peerServices ma
 		os.MkdirAll("./logs", 0755)
 
 		// Main log file with rotation
-		fileWriter := &lumberjack.Logger{
+		var fileWriter io.Writer = &lumberjack.Logger{
 			Filename:   logPath,
 			MaxSize:    50, // megabytes
 			MaxBackups: 5,
 			MaxAge:     0, // days (0 = don't delete old files)
 		}
-		fileLogger = log.New(fileWriter, "", 0)
-
-		// Error log file with rotation
-		errorWriter := &lumberjack.Logger{
-			Filename:   errorLogPath,
-			MaxSize:    10, // megabytes
-			MaxBackups: 3,
-			MaxAge:     0,
+		if syncEachWrite {
+			fileWriter = syncingFile{fileWriter.(*lumberjack.Logger)}
 		}
-		errorLogger = log.New(errorWriter, "", 0)
+		l.sinks = append(l.sinks, namedSink{name: "file", label: "File log", sink: newLineSink(fileWriter, fileFormatter), minSeverity: fileMinSeverity})
 
 		fmt.Printf("📝 File logging enabled: %s\n", logPath)
+		if syncEachWrite {
+			fmt.Printf("📝 File sync after each write enabled\n")
+		}
+
+		// Error log file with rotation; optional since dev.log already has every entry
+		if cfg.DisableErrorLog || !getEnvBool("SOVDEV_ERROR_LOG_ENABLED", true) {
+			fmt.Printf("📝 Error log file disabled\n")
+		} else {
+			var errorWriter io.Writer = &lumberjack.Logger{
+				Filename:   errorLogPath,
+				MaxSize:    positiveOr(cfg.ErrorLogMaxSizeMB, getEnvInt("SOVDEV_ERROR_LOG_MAX_SIZE_MB", 10)), // megabytes
+				MaxBackups: positiveOr(cfg.ErrorLogMaxBackups, getEnvInt("SOVDEV_ERROR_LOG_MAX_BACKUPS", 3)),
+				MaxAge:     positiveOr(cfg.ErrorLogMaxAgeDays, getEnvInt("SOVDEV_ERROR_LOG_MAX_AGE_DAYS", 0)), // days (0 = don't delete old files)
+				Compress:   cfg.ErrorLogCompress || getEnvBool("SOVDEV_ERROR_LOG_COMPRESS", false),
+			}
+			if syncEachWrite {
+				errorWriter = syncingFile{errorWriter.(*lumberjack.Logger)}
+			}
+			l.sinks = append(l.sinks, namedSink{name: "error_file", label: "Error log", sink: newLineSink(errorWriter, fileFormatter), accepts: isErrorEntry, minSeverity: fileMinSeverity})
+		}
+	}
+
+	// Tamper-evident audit log for log_type "audit"; a failed write is reported on
+	// every entry since the chain has a gap
+	auditLogPath := cfg.AuditLogPath
+	if auditLogPath == "" {
+		auditLogPath = os.Getenv("SOVDEV_AUDIT_LOG_PATH")
+	}
+	if auditLogPath != "" {
+		auditWriter, err := NewAuditLogWriter(auditLogPath)
+		if err != nil {
+			fmt.Printf("⚠️  Audit log disabled: %v\n", err)
+		} else {
+			auditWriter.syncEachWrite = syncEachWrite
+			l.sinks = append(l.sinks, namedSink{name: "audit", label: "Audit log", sink: auditWriter, accepts: isAuditEntry, reportAll: true})
+			fmt.Printf("🔒 Audit log enabled: %s\n", auditLogPath)
+		}
 	}
 
 	if logToConsole {
-		consoleLogger = log.New(os.Stdout, "", 0)
+		consoleFormatter := cfg.ConsoleFormatter
+		if consoleFormatter == nil {
+			consoleFormatter = formatterFromEnv(os.Getenv("SOVDEV_CONSOLE_FORMAT"))
+		}
+		if cfg.ConsoleErrorToStderr || getEnvBool("SOVDEV_CONSOLE_ERROR_TO_STDERR", false) {
+			// Twelve-factor style: ERROR/FATAL on stderr, the rest on stdout
+			l.sinks = append(l.sinks,
+				namedSink{name: "console", label: "Console", sink: newLineSink(os.Stdout, consoleFormatter), accepts: isNotErrorEntry, minSeverity: consoleMinSeverity},
+				namedSink{name: "console", label: "Console", sink: newLineSink(os.Stderr, consoleFormatter), accepts: isErrorEntry, minSeverity: consoleMinSeverity})
+		} else {
+			l.sinks = append(l.sinks, namedSink{name: "console", label: "Console", sink: newLineSink(os.Stdout, consoleFormatter), minSeverity: consoleMinSeverity})
+		}
+	}
+
+	if l.logProvider != nil {
+		l.otlpLogger = l.logProvider.Logger(serviceName)
+		l.sinks = append(l.sinks, namedSink{name: "otlp", label: "OTLP", sink: otlpSink{l: l}, minSeverity: otlpMinSeverity})
+	}
+
+	// Graylog output
+	gelfEndpoint := cfg.GELFEndpoint
+	if gelfEndpoint == "" {
+		gelfEndpoint = os.Getenv("SOVDEV_GELF_ENDPOINT")
+	}
+	if gelfEndpoint != "" {
+		gelfWriter, err := NewGELFWriter(gelfEndpoint)
+		if err != nil {
+			fmt.Printf("⚠️  GELF output disabled: %v\n", err)
+		} else {
+			l.sinks = append(l.sinks, namedSink{name: "gelf", label: "GELF", sink: gelfWriter})
+			fmt.Printf("📨 GELF output enabled: %s\n", gelfEndpoint)
+		}
+	}
+
+	// Splunk HTTP Event Collector output
+	splunkEndpoint := cfg.SplunkHECEndpoint
+	if splunkEndpoint == "" {
+		splunkEndpoint = os.Getenv("SOVDEV_SPLUNK_HEC_ENDPOINT")
+	}
+	if splunkEndpoint != "" {
+		splunkToken := cfg.SplunkHECToken
+		if splunkToken == "" {
+			splunkToken = os.Getenv("SOVDEV_SPLUNK_HEC_TOKEN")
+		}
+		splunkSink, err := NewSplunkHECSink(splunkEndpoint, splunkToken)
+		if err != nil {
+			fmt.Printf("⚠️  Splunk HEC output disabled: %v\n", err)
+		} else {
+			l.sinks = append(l.sinks, namedSink{name: "splunk", label: "Splunk HEC", sink: splunkSinkAdapter{s: splunkSink}})
+			fmt.Printf("📨 Splunk HEC output enabled: %s\n", splunkEndpoint)
+		}
 	}
 
-	var otlpLogger otlog.Logger
-	if globalLogProvider != nil {
-		otlpLogger = globalLogProvider.Logger(serviceName)
+	// Elasticsearch / OpenSearch _bulk output
+	elasticsearchEndpoint := cfg.ElasticsearchEndpoint
+	if elasticsearchEndpoint == "" {
+		elasticsearchEndpoint = os.Getenv("SOVDEV_ELASTICSEARCH_ENDPOINT")
+	}
+	if elasticsearchEndpoint != "" {
+		index := cfg.ElasticsearchIndex
+		if index == "" {
+			index = os.Getenv("SOVDEV_ELASTICSEARCH_INDEX")
+		}
+		apiKey := cfg.ElasticsearchAPIKey
+		if apiKey == "" {
+			apiKey = os.Getenv("SOVDEV_ELASTICSEARCH_API_KEY")
+		}
+		elasticsearchSink, err := NewElasticsearchSink(elasticsearchEndpoint, index, apiKey)
+		if err != nil {
+			fmt.Printf("⚠️  Elasticsearch output disabled: %v\n", err)
+		} else {
+			l.sinks = append(l.sinks, namedSink{name: "elasticsearch", label: "Elasticsearch", sink: elasticsearchSink})
+			fmt.Printf("📨 Elasticsearch output enabled: %s (index %s)\n", redactURL(elasticsearchEndpoint), elasticsearchSink.index)
+		}
 	}
 
-	globalLogger = &sovdevLogger{
-		serviceName:    serviceName,
-		serviceVersion: serviceVersion,
-		sessionID:      globalSessionID,
-		peerServiceMap: effectivePeerServices,
-		fileLogger:     fileLogger,
-		errorLogger:    errorLogger,
-		consoleLogger:  consoleLogger,
-		otlpLogger:     otlpLogger,
-		logToConsole:   logToConsole,
-		logToFile:      logToFile,
+	// Grafana Loki push output
+	lokiEndpoint := cfg.LokiEndpoint
+	if lokiEndpoint == "" {
+		lokiEndpoint = os.Getenv("SOVDEV_LOKI_ENDPOINT")
+	}
+	if lokiEndpoint != "" {
+		username := cfg.LokiUsername
+		if username == "" {
+			username = os.Getenv("SOVDEV_LOKI_USERNAME")
+		}
+		password := cfg.LokiPassword
+		if password == "" {
+			password = os.Getenv("SOVDEV_LOKI_PASSWORD")
+		}
+		lokiSink, err := NewLokiSink(lokiEndpoint, username, password)
+		if err != nil {
+			fmt.Printf("⚠️  Loki output disabled: %v\n", err)
+		} else {
+			lokiSink.labelValue = func(label, value string) string {
+				if label == "peer_service" {
+					return l.metricPeerService(value)
+				}
+				return l.metricLogType(SovdevLogType(value))
+			}
+			l.sinks = append(l.sinks, namedSink{name: "loki", label: "Loki", sink: lokiSink})
+			fmt.Printf("📨 Loki output enabled: %s\n", redactURL(lokiSink.endpoint))
+		}
+	}
+
+	// Caller-supplied outputs
+	for _, sink := range cfg.Sinks {
+		if sink != nil {
+			l.sinks = append(l.sinks, namedSink{name: "custom", label: fmt.Sprintf("Sink %T", sink), sink: sink})
+		}
+	}
+
+	minLevel := cfg.MinLevel
+	if minLevel == "" {
+		minLevel = SovdevLogLevel(strings.ToLower(getEnv("LOG_LEVEL", string(SOVDEV_LOGLEVELS.TRACE))))
+	}
+	if !isValidLevel(minLevel) {
+		fmt.Printf("⚠️  Invalid log level %q, logging everything\n", minLevel)
+		minLevel = SOVDEV_LOGLEVELS.TRACE
+	}
+	l.minSeverity.Store(int32(mapToSeverityNumber(minLevel)))
+	l.defaultSeverity.Store(int32(mapToSeverityNumber(SOVDEV_LOGLEVELS.INFO)))
+
+	if cfg.FlushOnLevel != "" {
+		if isValidLevel(cfg.FlushOnLevel) {
+			l.flushOnSeverity = int32(mapToSeverityNumber(cfg.FlushOnLevel))
+		} else {
+			fmt.Printf("⚠️  Invalid FlushOnLevel %q, flush on error disabled\n", cfg.FlushOnLevel)
+		}
+	}
+	if interval := flushInterval(cfg); interval > 0 {
+		l.periodicFlush = l.startPeriodicFlush(interval)
+		fmt.Printf("⏱️  Periodic flush every %s\n", interval)
 	}
 
 	fmt.Printf("🚀 Sovdev Logger initialized:\n")
 	fmt.Printf("   ├── Service: %s\n", serviceName)
 	fmt.Printf("   ├── Version: %s\n", serviceVersion)
-	fmt.Printf("   ├── Session: %s\n", globalSessionID)
+	fmt.Printf("   ├── Min level: %s\n", minLevel)
+	if l.dedup != nil {
+		fmt.Printf("   ├── Dedup window: %s\n", cfg.DedupWindow)
+	}
 	fmt.Printf("   ├── Console: %v\n", logToConsole)
 	fmt.Printf("   └── File: %v\n", logToFile)
 
-	return nil
+	return l, nil
 }
 
 // hostOverrideTransport is an HTTP RoundTripper that overrides the Host header
@@ -198,15 +577,106 @@ func (t *hostOverrideTransport) RoundTrip(req *http.Request) (*http.Response, er
 	return t.base.RoundTrip(req)
 }
 
-// createHTTPClientWithHost creates an HTTP client that forces a specific Host header
-func createHTTPClientWithHost(hostHeader string) *http.Client {
+// createExporterHTTPClient creates the HTTP client for one OTLP exporter. It
+// forces the Host header and/or adds a bearer token; nil means the exporter
+// defaults are sufficient. The exporters ignore WithTimeout with a custom
+// client, so the export timeout is set on the client.
+func createExporterHTTPClient(hostHeader string, token *tokenFile, timeout time.Duration) *http.Client {
+	if hostHeader == "" && token == nil {
+		return nil
+	}
+
+	var transport http.RoundTripper = http.DefaultTransport
+	if token != nil {
+		transport = &bearerTokenTransport{base: transport, token: token}
+	}
+	if hostHeader != "" {
+		transport = &hostOverrideTransport{base: transport, host: hostHeader}
+	}
+
 	return &http.Client{
-		Transport: &hostOverrideTransport{
-			base: http.DefaultTransport,
-			host: hostHeader,
-		},
-		Timeout: 30 * time.Second,
+		Transport: transport,
+		Timeout:   timeout,
+	}
+}
+
+// otlpEndpoint returns the URL for one signal: the signal-specific variable
+// (e.g. OTEL_EXPORTER_OTLP_LOGS_ENDPOINT) used as-is, otherwise
+// OTEL_EXPORTER_OTLP_ENDPOINT (default http://localhost:4318) with signalPath
+// appended to its path, so a collector behind a path-based ingress works with
+// "http://gw/otel" as well as "http://gw/otel/"
+func otlpEndpoint(signalKey, signalPath string) string {
+	if endpoint := os.Getenv(signalKey); endpoint != "" {
+		return endpoint
+	}
+
+	base := getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318")
+	hostStart := 0
+	if idx := strings.Index(base, "://"); idx != -1 {
+		hostStart = idx + len("://")
+	}
+	if idx := strings.Index(base[hostStart:], "/"); idx != -1 {
+		return base[:hostStart+idx] + joinOTLPPath(base[hostStart+idx:], signalPath)
+	}
+	return base + signalPath
+}
+
+// otlpPath returns the URL path for one signal: the Config override, the env
+// var key, or parsed (the path of the endpoint). A missing leading slash is added.
+func otlpPath(override, key, parsed string) string {
+	if override == "" {
+		override = strings.TrimSpace(os.Getenv(key))
+	}
+	if override == "" {
+		return parsed
+	}
+	if !strings.HasPrefix(override, "/") {
+		override = "/" + override
+	}
+	return override
+}
+
+// defaultOTLPTimeout is the per-export timeout from the OTLP exporter spec
+const defaultOTLPTimeout = 10 * time.Second
+
+// otlpTimeout returns the timeout for one export request of a signal:
+// Config.OTLPTimeout, the signal-specific variable (e.g.
+// OTEL_EXPORTER_OTLP_LOGS_TIMEOUT), OTEL_EXPORTER_OTLP_TIMEOUT (milliseconds) or
+// the 10s default
+func otlpTimeout(cfg Config, signalKey string) time.Duration {
+	if cfg.OTLPTimeout > 0 {
+		return cfg.OTLPTimeout
+	}
+	for _, key := range []string{signalKey, "OTEL_EXPORTER_OTLP_TIMEOUT"} {
+		value := strings.TrimSpace(os.Getenv(key))
+		if value == "" {
+			continue
+		}
+		ms, err := strconv.Atoi(value)
+		if err != nil || ms <= 0 {
+			fmt.Printf("⚠️  Invalid %s=%q (milliseconds), using %s\n", key, value, defaultOTLPTimeout)
+			return defaultOTLPTimeout
+		}
+		return time.Duration(ms) * time.Millisecond
+	}
+	return defaultOTLPTimeout
+}
+
+// otlpSignalPaths are the OTLP/HTTP paths for each signal
+var otlpSignalPaths = []string{"/v1/traces", "/v1/logs", "/v1/metrics"}
+
+// joinOTLPPath appends signalPath to basePath with exactly one slash between
+// them. A signal path already at the end of basePath (a signal URL set as the
+// base endpoint by mistake) is replaced rather than doubled to /v1/logs/v1/logs.
+func joinOTLPPath(basePath, signalPath string) string {
+	basePath = strings.TrimRight(basePath, "/")
+	for _, p := range otlpSignalPaths {
+		if strings.HasSuffix(basePath, p) {
+			basePath = strings.TrimSuffix(basePath, p)
+			break
+		}
 	}
+	return basePath + signalPath
 }
 
 // parseEndpoint extracts host and path from a full URL
@@ -272,166 +742,607 @@ func parseOTLPHeaders() map[string]string {
 	return headers
 }
 
-// initializeOpenTelemetry sets up OTLP exporters and providers
-func initializeOpenTelemetry(serviceName, serviceVersion, sessionID string) error {
+// newResource builds the OpenTelemetry resource from the detectors and the
+// service attributes. A failing detector (e.g. the process owner lookup when
+// running as a UID without a passwd entry) is not fatal: the attributes the other
+// detectors found are kept, or only the service attributes if there are none.
+func newResource(ctx context.Context, serviceAttrs []attribute.KeyValue, detectors ...resource.Option) *resource.Resource {
+	res, err := resource.New(ctx, append(detectors, resource.WithAttributes(serviceAttrs...))...)
+	if err == nil {
+		return res
+	}
+	if errors.Is(err, resource.ErrPartialResource) {
+		fmt.Printf("⚠️  Resource detection incomplete, continuing without the missing attributes: %v\n", err)
+	} else {
+		fmt.Printf("⚠️  Resource detector failed, continuing without its attributes: %v\n", err)
+	}
+	if res == nil {
+		return resource.NewSchemaless(serviceAttrs...)
+	}
+	return res
+}
+
+// initializeOpenTelemetry sets up OTLP exporters and providers for this logger
+func (l *Logger) initializeOpenTelemetry(cfg Config) error {
 	ctx := context.Background()
+	serviceName := cfg.ServiceName
+	serviceVersion := cfg.ServiceVersion
 
 	// Create resource
-	res, err := resource.New(ctx,
-		resource.WithAttributes(
+	// Instance ID distinguishes replicas of the same service
+	instanceID := cfg.ServiceInstanceID
+	if instanceID == "" {
+		instanceID = getEnv("SOVDEV_SERVICE_INSTANCE_ID", l.ids.NewID())
+	}
+	l.instanceID = instanceID
+
+	// VCS revision and commit time from the build, so telemetry links to the exact source
+	_, vcsAttrs := buildVersion()
+
+	res := cfg.Resource
+	var err error
+	if res == nil {
+		serviceAttrs := append([]attribute.KeyValue{
 			semconv.ServiceName(serviceName),
 			semconv.ServiceVersion(serviceVersion),
+			semconv.ServiceInstanceID(instanceID),
 			semconv.DeploymentEnvironment(getEnv("NODE_ENV", "development")),
-		),
-	)
-	if err != nil {
-		return fmt.Errorf("failed to create resource: %w", err)
+		}, vcsAttrs...)
+		res = newResource(ctx, serviceAttrs, resource.WithHost(), resource.WithProcess())
+	} else {
+		fmt.Printf("🏷️  Using caller-supplied OpenTelemetry resource\n")
 	}
 
 	// Parse headers from environment
 	headers := parseOTLPHeaders()
 	if headers != nil {
-		fmt.Printf("📋 OTLP headers configured: %v\n", headers)
+		// Credential values are masked; they must never reach stdout
+		fmt.Printf("📋 OTLP headers configured: %s\n", describeHeaders(headers))
+	}
+
+	// Bearer token from file, attached to all exporters
+	tokenPath := cfg.OTLPTokenFile
+	if tokenPath == "" {
+		tokenPath = os.Getenv("SOVDEV_OTLP_TOKEN_FILE")
+	}
+	var token *tokenFile
+	if tokenPath != "" {
+		token, err = newTokenFile(tokenPath)
+		if err != nil {
+			fmt.Printf("⚠️  OTLP token file not usable: %v\n", err)
+		} else {
+			fmt.Printf("🔐 OTLP bearer token loaded from %s\n", tokenPath)
+		}
+	}
+
+	// Compression applies to all exporters; empty leaves the exporters' own default (none)
+	compression := strings.ToLower(cfg.OTLPCompression)
+	if compression == "" {
+		compression = strings.ToLower(os.Getenv("OTEL_EXPORTER_OTLP_COMPRESSION"))
+	}
+	switch compression {
+	case "", "none":
+	case "gzip":
+		fmt.Printf("🗜️  OTLP gzip compression enabled\n")
+	default:
+		fmt.Printf("⚠️  Unknown OTLP compression %q, using none\n", compression)
+		compression = "none"
+	}
+
+	// Retry policy for all exporters
+	retry := otlpRetry(cfg)
+	if !retry.isDefault() {
+		fmt.Printf("🔁 OTLP retry: %s\n", retry)
 	}
 
 	// Trace exporter
-	traceEndpoint := getEnv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "http://localhost:4318/v1/traces")
+	traceEndpoint := otlpEndpoint("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "/v1/traces")
 	traceEndpointHost, traceEndpointPath := parseEndpoint(traceEndpoint)
+	traceEndpointPath = otlpPath(cfg.OTLPTracesPath, "SOVDEV_OTLP_TRACES_PATH", traceEndpointPath)
 	fmt.Printf("🔗 Trace endpoint: %s (path: %s)\n", traceEndpointHost, traceEndpointPath)
 
+	traceTimeout := otlpTimeout(cfg, "OTEL_EXPORTER_OTLP_TRACES_TIMEOUT")
 	traceExporterOpts := []otlptracehttp.Option{
 		otlptracehttp.WithEndpoint(traceEndpointHost),
 		otlptracehttp.WithInsecure(),
 		otlptracehttp.WithURLPath(traceEndpointPath),
+		otlptracehttp.WithTimeout(traceTimeout),
+	}
+	traceClient := createExporterHTTPClient(headers["Host"], token, traceTimeout)
+	if traceClient != nil {
+		// Use custom HTTP client that forces the Host header and/or adds the bearer token
+		traceExporterOpts = append(traceExporterOpts, otlptracehttp.WithHTTPClient(traceClient))
+		if headers["Host"] != "" {
+			fmt.Printf("   ├── Using custom Host header: %s\n", headers["Host"])
+		}
 	}
-	if headers != nil && headers["Host"] != "" {
-		// Use custom HTTP client that forces the Host header
-		httpClient := createHTTPClientWithHost(headers["Host"])
-		traceExporterOpts = append(traceExporterOpts, otlptracehttp.WithHTTPClient(httpClient))
-		fmt.Printf("   ├── Using custom Host header: %s\n", headers["Host"])
+	switch compression {
+	case "gzip":
+		traceExporterOpts = append(traceExporterOpts, otlptracehttp.WithCompression(otlptracehttp.GzipCompression))
+	case "none":
+		traceExporterOpts = append(traceExporterOpts, otlptracehttp.WithCompression(otlptracehttp.NoCompression))
 	}
+	traceExporterOpts = append(traceExporterOpts, otlptracehttp.WithRetry(otlptracehttp.RetryConfig(retry)))
 	traceExporter, err := otlptracehttp.New(ctx, traceExporterOpts...)
 	if err != nil {
 		fmt.Printf("⚠️  Trace exporter initialization failed: %v\n", err)
 		// Create a basic tracer provider even if exporter fails
-		tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithResource(res))
-		otel.SetTracerProvider(tracerProvider)
-		globalTracer = tracerProvider.Tracer(serviceName)
-		globalTraceProvider = tracerProvider
+		tracerProvider := sdktrace.NewTracerProvider(
+			sdktrace.WithSpanProcessor(globalAttributeProcessor{l: l}),
+			sdktrace.WithResource(res),
+		)
+		l.tracer = tracerProvider.Tracer(serviceName)
+		l.traceProvider = tracerProvider
 	} else {
 		tracerProvider := sdktrace.NewTracerProvider(
-			sdktrace.WithBatcher(traceExporter),
+			sdktrace.WithSpanProcessor(globalAttributeProcessor{l: l}),
+			sdktrace.WithBatcher(&failureTrackingSpanExporter{SpanExporter: traceExporter, logger: l}),
 			sdktrace.WithResource(res),
 		)
-		otel.SetTracerProvider(tracerProvider)
-		globalTracer = tracerProvider.Tracer(serviceName)
-		globalTraceProvider = tracerProvider
+		l.tracer = tracerProvider.Tracer(serviceName)
+		l.traceProvider = tracerProvider
+		l.otlpTargets = append(l.otlpTargets, otlpTarget{signal: "traces", url: "http://" + traceEndpointHost + traceEndpointPath, client: traceClient})
 	}
 
 	// Log exporter
-	logEndpoint := getEnv("OTEL_EXPORTER_OTLP_LOGS_ENDPOINT", "http://localhost:4318/v1/logs")
+	logEndpoint := otlpEndpoint("OTEL_EXPORTER_OTLP_LOGS_ENDPOINT", "/v1/logs")
 	logEndpointHost, logEndpointPath := parseEndpoint(logEndpoint)
+	logEndpointPath = otlpPath(cfg.OTLPLogsPath, "SOVDEV_OTLP_LOGS_PATH", logEndpointPath)
 	fmt.Printf("🔗 Log endpoint: %s (path: %s)\n", logEndpointHost, logEndpointPath)
 
+	logTimeout := otlpTimeout(cfg, "OTEL_EXPORTER_OTLP_LOGS_TIMEOUT")
 	logExporterOpts := []otlploghttp.Option{
 		otlploghttp.WithEndpoint(logEndpointHost),
 		otlploghttp.WithInsecure(),
 		otlploghttp.WithURLPath(logEndpointPath),
+		otlploghttp.WithTimeout(logTimeout),
 	}
-	if headers != nil && headers["Host"] != "" {
-		// Use custom HTTP client that forces the Host header
-		httpClient := createHTTPClientWithHost(headers["Host"])
-		logExporterOpts = append(logExporterOpts, otlploghttp.WithHTTPClient(httpClient))
-		fmt.Printf("   ├── Using custom Host header: %s\n", headers["Host"])
+	logClient := createExporterHTTPClient(headers["Host"], token, logTimeout)
+	if logClient != nil {
+		// Use custom HTTP client that forces the Host header and/or adds the bearer token
+		logExporterOpts = append(logExporterOpts, otlploghttp.WithHTTPClient(logClient))
+		if headers["Host"] != "" {
+			fmt.Printf("   ├── Using custom Host header: %s\n", headers["Host"])
+		}
 	}
+	switch compression {
+	case "gzip":
+		logExporterOpts = append(logExporterOpts, otlploghttp.WithCompression(otlploghttp.GzipCompression))
+	case "none":
+		logExporterOpts = append(logExporterOpts, otlploghttp.WithCompression(otlploghttp.NoCompression))
+	}
+	logExporterOpts = append(logExporterOpts, otlploghttp.WithRetry(otlploghttp.RetryConfig(retry)))
 	logExporter, err := otlploghttp.New(ctx, logExporterOpts...)
 	if err != nil {
 		fmt.Printf("⚠️  Log exporter initialization failed: %v\n", err)
 		// Create a minimal log provider even if exporter fails
-		globalLogProvider = sdklog.NewLoggerProvider(sdklog.WithResource(res))
+		l.logProvider = sdklog.NewLoggerProvider(sdklog.WithResource(res))
 	} else {
 		logProvider := sdklog.NewLoggerProvider(
-			sdklog.WithProcessor(sdklog.NewBatchProcessor(logExporter)),
+			sdklog.WithProcessor(sdklog.NewBatchProcessor(&failureTrackingLogExporter{Exporter: logExporter, logger: l})),
 			sdklog.WithResource(res),
 		)
-		globalLogProvider = logProvider
+		l.logProvider = logProvider
+		l.otlpTargets = append(l.otlpTargets, otlpTarget{signal: "logs", url: "http://" + logEndpointHost + logEndpointPath, client: logClient})
 	}
 
-	// Metric exporter
-	metricEndpoint := getEnv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT", "http://localhost:4318/v1/metrics")
-	metricEndpointHost, metricEndpointPath := parseEndpoint(metricEndpoint)
-	fmt.Printf("🔗 Metric endpoint: %s (path: %s)\n", metricEndpointHost, metricEndpointPath)
+	// Metric readers
+	var meterProviderOpts []sdkmetric.Option
+	var metricReaders int
+	meterProviderOpts = append(meterProviderOpts, sdkmetric.WithResource(res))
+
+	// Exemplars attach the trace_id of the active span to duration measurements, so a
+	// slow outlier in sovdev.operation.duration links to its trace. Empty leaves the
+	// SDK default (trace_based, or OTEL_METRICS_EXEMPLAR_FILTER).
+	switch strings.ToLower(cfg.ExemplarFilter) {
+	case "":
+	case "trace_based":
+		meterProviderOpts = append(meterProviderOpts, sdkmetric.WithExemplarFilter(exemplar.TraceBasedFilter))
+	case "always_on":
+		meterProviderOpts = append(meterProviderOpts, sdkmetric.WithExemplarFilter(exemplar.AlwaysOnFilter))
+	case "always_off":
+		meterProviderOpts = append(meterProviderOpts, sdkmetric.WithExemplarFilter(exemplar.AlwaysOffFilter))
+	default:
+		fmt.Printf("⚠️  Unknown exemplar filter %q, using SDK default\n", cfg.ExemplarFilter)
+	}
 
-	metricExporterOpts := []otlpmetrichttp.Option{
-		otlpmetrichttp.WithEndpoint(metricEndpointHost),
-		otlpmetrichttp.WithInsecure(),
-		otlpmetrichttp.WithURLPath(metricEndpointPath),
+	// Explicit buckets for operation duration; the SDK defaults are too coarse below 5ms
+	durationBuckets := cfg.DurationBuckets
+	if len(durationBuckets) == 0 {
+		durationBuckets = defaultDurationBuckets
 	}
-	if headers != nil && headers["Host"] != "" {
-		// Use custom HTTP client that forces the Host header
-		httpClient := createHTTPClientWithHost(headers["Host"])
-		metricExporterOpts = append(metricExporterOpts, otlpmetrichttp.WithHTTPClient(httpClient))
-		fmt.Printf("   ├── Using custom Host header: %s\n", headers["Host"])
+	meterProviderOpts = append(meterProviderOpts, sdkmetric.WithView(sdkmetric.NewView(
+		sdkmetric.Instrument{Name: "sovdev.operation.duration"},
+		sdkmetric.Stream{Aggregation: sdkmetric.AggregationExplicitBucketHistogram{Boundaries: durationBuckets}},
+	)))
+
+	if cfg.PrometheusMetrics {
+		// Prometheus exporter is a pull reader with cumulative temporality
+		registry := prometheus.NewRegistry()
+		promExporter, err := otelprom.New(otelprom.WithRegisterer(registry))
+		if err != nil {
+			fmt.Printf("⚠️  Prometheus exporter initialization failed: %v\n", err)
+		} else {
+			meterProviderOpts = append(meterProviderOpts, sdkmetric.WithReader(promExporter))
+			metricReaders++
+			// OpenMetrics is the only Prometheus exposition format that carries exemplars
+			l.metricsHandler = promhttp.HandlerFor(registry, promhttp.HandlerOpts{EnableOpenMetrics: true})
+			fmt.Printf("📊 Prometheus metrics enabled (mount SovdevMetricsHandler at /metrics)\n")
+		}
 	}
-	metricExporter, err := otlpmetrichttp.New(ctx, metricExporterOpts...)
-	if err != nil {
-		fmt.Printf("⚠️  Metric exporter initialization failed: %v\n", err)
-		// Create a basic meter provider even if exporter fails
-		meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithResource(res))
-		otel.SetMeterProvider(meterProvider)
-		globalMeter = meterProvider.Meter(serviceName)
-		globalMeterProvider = meterProvider
+
+	if cfg.DisableOTLPMetrics {
+		fmt.Printf("🔗 OTLP metric export disabled\n")
 	} else {
-		// Create periodic reader with CUMULATIVE temporality (Prometheus compatible)
-		// Use manual reader with temporality preference, then wrap in periodic
-		reader := sdkmetric.NewPeriodicReader(
-			metricExporter,
-			sdkmetric.WithInterval(10*time.Second), // Export every 10 seconds
-		)
+		metricEndpoint := otlpEndpoint("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT", "/v1/metrics")
+		metricEndpointHost, metricEndpointPath := parseEndpoint(metricEndpoint)
+		metricEndpointPath = otlpPath(cfg.OTLPMetricsPath, "SOVDEV_OTLP_METRICS_PATH", metricEndpointPath)
+		fmt.Printf("🔗 Metric endpoint: %s (path: %s)\n", metricEndpointHost, metricEndpointPath)
+
+		metricTimeout := otlpTimeout(cfg, "OTEL_EXPORTER_OTLP_METRICS_TIMEOUT")
+		metricExporterOpts := []otlpmetrichttp.Option{
+			otlpmetrichttp.WithEndpoint(metricEndpointHost),
+			otlpmetrichttp.WithInsecure(),
+			otlpmetrichttp.WithURLPath(metricEndpointPath),
+			otlpmetrichttp.WithTimeout(metricTimeout),
+		}
+		metricClient := createExporterHTTPClient(headers["Host"], token, metricTimeout)
+		if metricClient != nil {
+			// Use custom HTTP client that forces the Host header and/or adds the bearer token
+			metricExporterOpts = append(metricExporterOpts, otlpmetrichttp.WithHTTPClient(metricClient))
+			if headers["Host"] != "" {
+				fmt.Printf("   ├── Using custom Host header: %s\n", headers["Host"])
+			}
+		}
+		switch compression {
+		case "gzip":
+			metricExporterOpts = append(metricExporterOpts, otlpmetrichttp.WithCompression(otlpmetrichttp.GzipCompression))
+		case "none":
+			metricExporterOpts = append(metricExporterOpts, otlpmetrichttp.WithCompression(otlpmetrichttp.NoCompression))
+		}
+		metricExporterOpts = append(metricExporterOpts, otlpmetrichttp.WithRetry(otlpmetrichttp.RetryConfig(retry)))
+		// Temporality is set explicitly rather than left to the exporter default.
+		// Cumulative suits Prometheus-style backends; delta suits Dynatrace and others
+		// that expect per-interval increments.
+		temporality := strings.ToLower(cfg.MetricsTemporality)
+		if temporality == "" {
+			temporality = strings.ToLower(getEnv("OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE", "cumulative"))
+		}
+		selector, ok := temporalitySelectors[temporality]
+		if !ok {
+			fmt.Printf("⚠️  Unknown metrics temporality %q, using cumulative\n", temporality)
+			temporality, selector = "cumulative", temporalitySelectors["cumulative"]
+		}
+		metricExporterOpts = append(metricExporterOpts, otlpmetrichttp.WithTemporalitySelector(selector))
 
-		// Set cumulative temporality using the exporter's temporality selector
-		meterProvider := sdkmetric.NewMeterProvider(
-			sdkmetric.WithReader(reader),
-			sdkmetric.WithResource(res),
-		)
-		otel.SetMeterProvider(meterProvider)
-		globalMeter = meterProvider.Meter(serviceName)
-		globalMeterProvider = meterProvider
-		fmt.Printf("   ├── Metric export interval: 10s\n")
+		metricExporter, err := otlpmetrichttp.New(ctx, metricExporterOpts...)
+		if err != nil {
+			fmt.Printf("⚠️  Metric exporter initialization failed: %v\n", err)
+		} else {
+			reader := sdkmetric.NewPeriodicReader(
+				&failureTrackingMetricExporter{Exporter: metricExporter, logger: l},
+				sdkmetric.WithInterval(10*time.Second), // Export every 10 seconds
+			)
+			meterProviderOpts = append(meterProviderOpts, sdkmetric.WithReader(reader))
+			metricReaders++
+			l.otlpTargets = append(l.otlpTargets, otlpTarget{signal: "metrics", url: "http://" + metricEndpointHost + metricEndpointPath, client: metricClient})
+			fmt.Printf("   ├── Metric export interval: 10s\n")
+			fmt.Printf("   ├── Metric temporality: %s\n", temporality)
+		}
 	}
 
+	// Create the meter provider even if no reader could be configured
+	meterProvider := sdkmetric.NewMeterProvider(meterProviderOpts...)
+	l.meter = meterProvider.Meter(serviceName)
+	l.meterProvider = meterProvider
+
 	// Initialize metrics (matching TypeScript implementation)
-	operationCounter, _ = globalMeter.Int64Counter("sovdev.operations.total",
+	l.operationCounter, _ = l.meter.Int64Counter("sovdev.operations.total",
 		metric.WithDescription("Total number of operations"))
-	errorCounter, _ = globalMeter.Int64Counter("sovdev.errors.total",
+	l.errorCounter, _ = l.meter.Int64Counter("sovdev.errors.total",
 		metric.WithDescription("Total number of errors"))
-	operationDuration, _ = globalMeter.Float64Histogram("sovdev.operation.duration",
+	l.operationDuration, _ = l.meter.Float64Histogram("sovdev.operation.duration",
 		metric.WithDescription("Duration of operations in milliseconds"),
 		metric.WithUnit("ms"))
-	activeOperations, _ = globalMeter.Int64UpDownCounter("sovdev.operations.active",
+	l.activeOperations, _ = l.meter.Int64UpDownCounter("sovdev.operations.active",
 		metric.WithDescription("Number of active operations"))
+	l.jobCounter, _ = l.meter.Int64Counter("sovdev.jobs.total",
+		metric.WithDescription("Total number of job status reports"))
+	l.jobDuration, _ = l.meter.Float64Histogram("sovdev.job.duration",
+		metric.WithDescription("Duration of jobs in milliseconds"),
+		metric.WithUnit("ms"))
+	l.exportFailures, _ = l.meter.Int64Counter("sovdev.export.failures",
+		metric.WithDescription("Number of failed OTLP exports by signal"))
+
+	// Instance heartbeat, only when some reader collects metrics
+	if metricReaders > 0 && !cfg.DisableServiceUpMetric && getEnvBool("SOVDEV_SERVICE_UP_METRIC_ENABLED", true) {
+		if err := l.registerServiceUp(); err != nil {
+			fmt.Printf("⚠️  sovdev.service.up metric not registered: %v\n", err)
+		}
+	}
 
 	fmt.Printf("📡 OpenTelemetry configured\n")
 	return nil
 }
 
-// SovdevLog logs a general transaction with optional input/output and exception
+// SovdevLog logs a general transaction with optional input/output and exception.
+// traceID is a W3C trace ID (32 hex characters, e.g. from SovdevGenerateTraceID);
+// UUIDs with dashes and uppercase hex are normalized, empty generates a new one,
+// and anything else is replaced with a generated ID and a validation_warning.
 func SovdevLog(level SovdevLogLevel, functionName, message, peerService string, inputJSON, responseJSON interface{}, exception error, traceID string) {
-	if globalLogger == nil {
-		fmt.Println("⚠️  Logger not initialized. Call SovdevInitialize first.")
+	logger := defaultLogger()
+	if logger == nil {
+		bufferPreInit(func(ctx context.Context, l *Logger) error {
+			return l.LogContext(ctx, level, functionName, message, peerService, inputJSON, responseJSON, exception, traceID)
+		})
+		return
+	}
+
+	if err := logger.Log(level, functionName, message, peerService, inputJSON, responseJSON, exception, traceID); err != nil {
+		fmt.Printf("⚠️  Log entry rejected: %v\n", err)
+	}
+}
+
+// SovdevLogContext logs a general transaction using the span, correlation ID and
+// other values carried in ctx
+func SovdevLogContext(ctx context.Context, level SovdevLogLevel, functionName, message, peerService string, inputJSON, responseJSON interface{}, exception error, traceID string) {
+	logger := defaultLogger()
+	if logger == nil {
+		bufferPreInit(func(replayCtx context.Context, l *Logger) error {
+			// Keep the caller's values (correlation ID, tenant, span) plus the original time
+			return l.LogContext(withEntryTime(ctx, entryTime(replayCtx)), level, functionName, message, peerService, inputJSON, responseJSON, exception, traceID)
+		})
+		return
+	}
+
+	if err := logger.LogContext(ctx, level, functionName, message, peerService, inputJSON, responseJSON, exception, traceID); err != nil {
+		fmt.Printf("⚠️  Log entry rejected: %v\n", err)
+	}
+}
+
+// SovdevLogAttrs logs a general transaction with custom attributes that are
+// neither input nor response, e.g. {"http.status_code": 200}. They appear as a
+// top-level "attributes" object in the entry and as individual OTLP attributes.
+func SovdevLogAttrs(level SovdevLogLevel, functionName, message, peerService string, inputJSON, responseJSON interface{}, exception error, traceID string, attributes map[string]interface{}) {
+	logger := defaultLogger()
+	if logger == nil {
+		bufferPreInit(func(ctx context.Context, l *Logger) error {
+			return l.logAttrs(ctx, level, functionName, message, peerService, inputJSON, responseJSON, exception, traceID, SOVDEV_LOGTYPES.TRANSACTION, attributes)
+		})
 		return
 	}
 
-	globalLogger.log(level, functionName, message, peerService, inputJSON, responseJSON, exception, traceID, "transaction")
+	if err := logger.LogAttrs(level, functionName, message, peerService, inputJSON, responseJSON, exception, traceID, attributes); err != nil {
+		fmt.Printf("⚠️  Log entry rejected: %v\n", err)
+	}
 }
 
-// SovdevLogJobStatus logs job status events (Started, Completed, Failed)
+// SovdevDebugf logs a DEBUG entry whose message and input are built lazily
+//
+// fn is only invoked when DEBUG passes the current min level, so expensive
+// payload construction costs nothing in hot paths when DEBUG is disabled:
+//
+//	sovdevlogger.SovdevDebugf("processItem", func() (string, interface{}) {
+//	    return fmt.Sprintf("Cache state for %s", key), buildCacheSnapshot()
+//	})
+func SovdevDebugf(functionName string, fn func() (message string, input interface{})) {
+	logger := defaultLogger()
+	if logger == nil {
+		return
+	}
+	logger.Debugf(functionName, fn)
+}
+
+// SovdevTracef logs a TRACE entry whose message and input are built lazily (see SovdevDebugf)
+func SovdevTracef(functionName string, fn func() (message string, input interface{})) {
+	logger := defaultLogger()
+	if logger == nil {
+		return
+	}
+	logger.Tracef(functionName, fn)
+}
+
+// SovdevLogJobStatus logs job status events (Started, Completed, Failed).
+// Completed/Failed entries get duration_ms since the Started entry of the same
+// job name, also recorded in the sovdev.job.duration histogram; without a
+// matching Started entry they are logged without a duration.
 func SovdevLogJobStatus(level SovdevLogLevel, functionName, jobName, status, peerService string, inputJSON interface{}, traceID string) {
-	if globalLogger == nil {
-		fmt.Println("⚠️  Logger not initialized. Call SovdevInitialize first.")
+	logger := defaultLogger()
+	if logger == nil {
+		bufferPreInit(func(ctx context.Context, l *Logger) error {
+			l.logJobStatus(ctx, level, functionName, jobName, status, peerService, inputJSON, traceID)
+			return nil
+		})
 		return
 	}
 
+	logger.LogJobStatus(level, functionName, jobName, status, peerService, inputJSON, traceID)
+}
+
+// SovdevLogJobStatusContext logs a job status event using the span and other
+// values in ctx, e.g. the job span from SovdevStartJobSpan, so the status entries
+// share the job's trace with its progress entries. An empty jobName uses the
+// span's job name.
+func SovdevLogJobStatusContext(ctx context.Context, level SovdevLogLevel, functionName, jobName, status, peerService string, inputJSON interface{}, traceID string) {
+	logger := defaultLogger()
+	if logger == nil {
+		bufferPreInit(func(replayCtx context.Context, l *Logger) error {
+			l.logJobStatus(withEntryTime(ctx, entryTime(replayCtx)), level, functionName, jobName, status, peerService, inputJSON, traceID)
+			return nil
+		})
+		return
+	}
+
+	logger.LogJobStatusContext(ctx, level, functionName, jobName, status, peerService, inputJSON, traceID)
+}
+
+// SovdevLogJobProgress logs progress for batch operations; see Config.ThrottleJobProgress
+// and Config.JobProgressEvery to limit entries for large jobs
+func SovdevLogJobProgress(level SovdevLogLevel, functionName, itemID string, current, total int, peerService string, inputJSON interface{}, traceID string) {
+	logger := defaultLogger()
+	if logger == nil {
+		bufferPreInit(func(ctx context.Context, l *Logger) error {
+			l.logJobProgress(ctx, level, functionName, itemID, current, total, peerService, inputJSON, traceID)
+			return nil
+		})
+		return
+	}
+
+	logger.LogJobProgress(level, functionName, itemID, current, total, peerService, inputJSON, traceID)
+}
+
+// SovdevLogJobProgressContext logs job progress using the span and other values in
+// ctx, e.g. the job span from SovdevStartJobSpan, whose job name replaces the
+// default job_name
+func SovdevLogJobProgressContext(ctx context.Context, level SovdevLogLevel, functionName, itemID string, current, total int, peerService string, inputJSON interface{}, traceID string) {
+	logger := defaultLogger()
+	if logger == nil {
+		bufferPreInit(func(replayCtx context.Context, l *Logger) error {
+			l.logJobProgress(withEntryTime(ctx, entryTime(replayCtx)), level, functionName, itemID, current, total, peerService, inputJSON, traceID)
+			return nil
+		})
+		return
+	}
+
+	logger.LogJobProgressContext(ctx, level, functionName, itemID, current, total, peerService, inputJSON, traceID)
+}
+
+// SovdevSessionID returns the default logger's session_id, or "" before
+// SovdevInitialize. It is not printed at startup, since stdout may be collected
+// where the session ID should not appear; pass it on explicitly where needed
+// (e.g. as SOVDEV_SESSION_ID to the workers of a batch).
+func SovdevSessionID() string {
+	logger := defaultLogger()
+	if logger == nil {
+		return ""
+	}
+	return logger.SessionID()
+}
+
+// SessionID returns the session_id written on this logger's entries
+func (l *Logger) SessionID() string {
+	return l.sessionID
+}
+
+// ServiceInfo describes a running logger, e.g. for a health or version endpoint
+type ServiceInfo struct {
+	ServiceName    string
+	ServiceVersion string // as resolved, e.g. from build info when not configured
+	InstanceID     string // service.instance.id (not added to a Config.Resource)
+	SessionID      string
+}
+
+// SovdevServiceInfo returns the default logger's service info, or a zero
+// ServiceInfo before SovdevInitialize
+func SovdevServiceInfo() ServiceInfo {
+	logger := defaultLogger()
+	if logger == nil {
+		return ServiceInfo{}
+	}
+	return logger.ServiceInfo()
+}
+
+// ServiceInfo returns this logger's service info
+func (l *Logger) ServiceInfo() ServiceInfo {
+	return ServiceInfo{
+		ServiceName:    l.serviceName,
+		ServiceVersion: l.serviceVersion,
+		InstanceID:     l.instanceID,
+		SessionID:      l.sessionID,
+	}
+}
+
+// SovdevGenerateTraceID generates a random W3C trace ID for transaction correlation
+// (32 lowercase hex characters, never all zero)
+func SovdevGenerateTraceID() string {
+	var id apitrace.TraceID
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return id.String()
+}
+
+// SovdevGenerateSpanID generates a random W3C span ID (16 lowercase hex characters,
+// never all zero), for correlating manually created entries with a parent operation
+func SovdevGenerateSpanID() string {
+	var id apitrace.SpanID
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return id.String()
+}
+
+// SovdevFlush flushes all pending telemetry of the default logger (30 second timeout)
+func SovdevFlush() error {
+	logger := defaultLogger()
+	if logger == nil {
+		return nil
+	}
+	return logger.Flush()
+}
+
+// SovdevFlushContext flushes all pending telemetry of the default logger within the
+// caller's deadline, e.g. the remaining termination grace period on SIGTERM
+func SovdevFlushContext(ctx context.Context) error {
+	logger := defaultLogger()
+	if logger == nil {
+		return nil
+	}
+	return logger.FlushContext(ctx)
+}
+
+// Log logs a general transaction with optional input/output and exception.
+// The returned error is only non-nil when Config.StrictValidation rejects the entry.
+func (l *Logger) Log(level SovdevLogLevel, functionName, message, peerService string, inputJSON, responseJSON interface{}, exception error, traceID string) error {
+	return l.log(context.Background(), level, functionName, message, peerService, inputJSON, responseJSON, exception, traceID, SOVDEV_LOGTYPES.TRANSACTION)
+}
+
+// LogContext logs a general transaction using the span, correlation ID and
+// other values carried in ctx (see Log for the returned error)
+func (l *Logger) LogContext(ctx context.Context, level SovdevLogLevel, functionName, message, peerService string, inputJSON, responseJSON interface{}, exception error, traceID string) error {
+	return l.log(ctx, level, functionName, message, peerService, inputJSON, responseJSON, exception, traceID, SOVDEV_LOGTYPES.TRANSACTION)
+}
+
+// LogAttrs logs a general transaction with custom attributes such as
+// http.status_code or db.rows_affected (see SovdevLogAttrs)
+func (l *Logger) LogAttrs(level SovdevLogLevel, functionName, message, peerService string, inputJSON, responseJSON interface{}, exception error, traceID string, attributes map[string]interface{}) error {
+	return l.logAttrs(context.Background(), level, functionName, message, peerService, inputJSON, responseJSON, exception, traceID, SOVDEV_LOGTYPES.TRANSACTION, attributes)
+}
+
+// Debugf logs a DEBUG entry whose message and input are built lazily (see SovdevDebugf)
+func (l *Logger) Debugf(functionName string, fn func() (message string, input interface{})) {
+	l.logLazy(SOVDEV_LOGLEVELS.DEBUG, functionName, fn)
+}
+
+// Tracef logs a TRACE entry whose message and input are built lazily (see SovdevDebugf)
+func (l *Logger) Tracef(functionName string, fn func() (message string, input interface{})) {
+	l.logLazy(SOVDEV_LOGLEVELS.TRACE, functionName, fn)
+}
+
+func (l *Logger) logLazy(level SovdevLogLevel, functionName string, fn func() (string, interface{})) {
+	if !l.enabled(level) {
+		return
+	}
+
+	message, input := fn()
+	l.log(context.Background(), level, functionName, message, l.selfPeerName, input, nil, nil, "", SOVDEV_LOGTYPES.TRANSACTION)
+}
+
+// LogJobStatus logs job status events (Started, Completed, Failed)
+func (l *Logger) LogJobStatus(level SovdevLogLevel, functionName, jobName, status, peerService string, inputJSON interface{}, traceID string) {
+	l.logJobStatus(context.Background(), level, functionName, jobName, status, peerService, inputJSON, traceID)
+}
+
+// LogJobStatusContext logs a job status event using the span and other values in
+// ctx (see SovdevLogJobStatusContext)
+func (l *Logger) LogJobStatusContext(ctx context.Context, level SovdevLogLevel, functionName, jobName, status, peerService string, inputJSON interface{}, traceID string) {
+	l.logJobStatus(ctx, level, functionName, jobName, status, peerService, inputJSON, traceID)
+}
+
+func (l *Logger) logJobStatus(ctx context.Context, level SovdevLogLevel, functionName, jobName, status, peerService string, inputJSON interface{}, traceID string) {
+	if jobName == "" {
+		jobName = jobNameFromContext(ctx)
+	}
 	// Add job metadata to input
 	enrichedInput := map[string]interface{}{
 		"job_name":   jobName,
@@ -445,14 +1356,44 @@ func SovdevLogJobStatus(level SovdevLogLevel, functionName, jobName, status, pee
 		}
 	}
 
+	// Elapsed time since the matching Started status; a caller-supplied duration_ms wins
+	duration, finished := l.jobStatusDuration(ctx, jobName, status)
+	durationMs := float64(duration) / float64(time.Millisecond)
+	if _, ok := enrichedInput["duration_ms"]; finished && !ok {
+		enrichedInput["duration_ms"] = durationMs
+	}
+
 	message := fmt.Sprintf("Job %s: %s", status, jobName)
-	globalLogger.log(level, functionName, message, peerService, enrichedInput, nil, nil, traceID, "job.status")
+	l.log(ctx, level, functionName, message, peerService, enrichedInput, nil, nil, traceID, SOVDEV_LOGTYPES.JOB_STATUS)
+
+	if finished && l.jobDuration != nil {
+		l.jobDuration.Record(ctx, durationMs, metric.WithAttributes(
+			attribute.String("job_name", jobName),
+			attribute.String("job_status", status),
+		))
+	}
 }
 
-// SovdevLogJobProgress logs progress for batch operations
-func SovdevLogJobProgress(level SovdevLogLevel, functionName, itemID string, current, total int, peerService string, inputJSON interface{}, traceID string) {
-	if globalLogger == nil {
-		fmt.Println("⚠️  Logger not initialized. Call SovdevInitialize first.")
+// LogJobProgress logs progress for batch operations
+func (l *Logger) LogJobProgress(level SovdevLogLevel, functionName, itemID string, current, total int, peerService string, inputJSON interface{}, traceID string) {
+	l.logJobProgress(context.Background(), level, functionName, itemID, current, total, peerService, inputJSON, traceID)
+}
+
+// LogJobProgressContext logs job progress using the span and other values in ctx
+// (see SovdevLogJobProgressContext)
+func (l *Logger) LogJobProgressContext(ctx context.Context, level SovdevLogLevel, functionName, itemID string, current, total int, peerService string, inputJSON interface{}, traceID string) {
+	l.logJobProgress(ctx, level, functionName, itemID, current, total, peerService, inputJSON, traceID)
+}
+
+func (l *Logger) logJobProgress(ctx context.Context, level SovdevLogLevel, functionName, itemID string, current, total int, peerService string, inputJSON interface{}, traceID string) {
+	if !l.shouldLogProgress(current, total) {
+		// Still count the item, as if the entry had been written
+		if level == "" || !isValidLevel(level) {
+			level = l.DefaultLevel()
+		}
+		if l.operationCounter != nil && l.enabled(level) {
+			l.countOperation(ctx, level, l.peerServiceFor(ctx, peerService), SOVDEV_LOGTYPES.JOB_PROGRESS, tenantIDFromContext(ctx))
+		}
 		return
 	}
 
@@ -466,6 +1407,9 @@ func SovdevLogJobProgress(level SovdevLogLevel, functionName, itemID string, cur
 		"progress_percentage": progressPercentage,
 		"job_name":            "BatchProcessing",
 	}
+	if jobName := jobNameFromContext(ctx); jobName != "" {
+		enrichedInput["job_name"] = jobName
+	}
 	if inputJSON != nil {
 		if inputMap, ok := inputJSON.(map[string]interface{}); ok {
 			for k, v := range inputMap {
@@ -475,42 +1419,58 @@ func SovdevLogJobProgress(level SovdevLogLevel, functionName, itemID string, cur
 	}
 
 	message := fmt.Sprintf("Processing %s (%d/%d)", itemID, current, total)
-	globalLogger.log(level, functionName, message, peerService, enrichedInput, nil, nil, traceID, "job.progress")
+	l.log(ctx, level, functionName, message, peerService, enrichedInput, nil, nil, traceID, SOVDEV_LOGTYPES.JOB_PROGRESS)
 }
 
-// SovdevGenerateTraceID generates a UUID for transaction correlation
-func SovdevGenerateTraceID() string {
-	return strings.ReplaceAll(uuid.New().String(), "-", "")
-}
+// defaultFlushTimeout bounds Flush when the caller does not supply a deadline
+const defaultFlushTimeout = 30 * time.Second
 
-// SovdevFlush flushes all pending telemetry
-func SovdevFlush() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+// Flush flushes all pending telemetry of this logger (30 second timeout)
+func (l *Logger) Flush() error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultFlushTimeout)
 	defer cancel()
 
+	return l.FlushContext(ctx)
+}
+
+// FlushContext flushes all pending telemetry of this logger within ctx's deadline
+func (l *Logger) FlushContext(ctx context.Context) error {
 	var errs []error
 
-	if globalTraceProvider != nil {
+	// Write pending "repeated" summaries so they are part of this flush
+	if l.dedup != nil {
+		l.dedup.flush()
+	}
+
+	if l.traceProvider != nil {
 		fmt.Println("🔄 Flushing OpenTelemetry traces...")
-		if err := globalTraceProvider.ForceFlush(ctx); err != nil {
+		if err := l.traceProvider.ForceFlush(ctx); err != nil {
 			errs = append(errs, fmt.Errorf("trace flush: %w", err))
 		} else {
 			fmt.Println("✅ OpenTelemetry traces flushed")
 		}
 	}
 
-	if globalMeterProvider != nil {
+	if l.meterProvider != nil {
 		fmt.Println("🔄 Flushing OpenTelemetry metrics...")
-		if err := globalMeterProvider.ForceFlush(ctx); err != nil {
+		if err := l.meterProvider.ForceFlush(ctx); err != nil {
 			errs = append(errs, fmt.Errorf("metric flush: %w", err))
 		} else {
 			fmt.Println("✅ OpenTelemetry metrics flushed")
 		}
 	}
 
-	if globalLogProvider != nil {
+	for _, s := range l.sinks {
+		if f, ok := s.sink.(sinkFlusher); ok {
+			if err := f.Flush(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("%s flush: %w", s.name, err))
+			}
+		}
+	}
+
+	if l.logProvider != nil {
 		fmt.Println("🔄 Flushing OpenTelemetry logs...")
-		if err := globalLogProvider.ForceFlush(ctx); err != nil {
+		if err := l.logProvider.ForceFlush(ctx); err != nil {
 			errs = append(errs, fmt.Errorf("log flush: %w", err))
 		} else {
 			fmt.Println("✅ OpenTelemetry logs flushed")
@@ -524,112 +1484,320 @@ func SovdevFlush() error {
 	return nil
 }
 
+// Shutdown flushes and stops this logger's OpenTelemetry providers.
+// It is safe to call more than once: later calls wait for the first to finish
+// and return its result. The logger must not be used afterwards.
+func (l *Logger) Shutdown(ctx context.Context) error {
+	l.shutdownOnce.Do(func() {
+		l.shutdownErr = l.shutdown(ctx)
+	})
+	return l.shutdownErr
+}
+
+func (l *Logger) shutdown(ctx context.Context) error {
+	var errs []error
+
+	l.debugOverride.stop()
+	l.periodicFlush.close()
+
+	if l.dedup != nil {
+		l.dedup.flush()
+	}
+
+	// Log pending SDK diagnostics while the providers still export, then stop
+	// the worker so it no longer keeps this logger alive
+	l.otelInternal.close(ctx)
+
+	if l.traceProvider != nil {
+		if err := l.traceProvider.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("trace shutdown: %w", err))
+		}
+	}
+
+	if l.meterProvider != nil {
+		if err := l.meterProvider.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("metric shutdown: %w", err))
+		}
+	}
+
+	if l.logProvider != nil {
+		if err := l.logProvider.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("log shutdown: %w", err))
+		}
+	}
+
+	for _, s := range l.sinks {
+		if err := closeSink(ctx, s.sink); err != nil {
+			errs = append(errs, fmt.Errorf("%s close: %w", s.name, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("shutdown errors: %v", errs)
+	}
+
+	return nil
+}
+
+// enabled reports whether entries at level pass the current min level
+func (l *Logger) enabled(level SovdevLogLevel) bool {
+	return int32(mapToSeverityNumber(level)) >= l.minSeverity.Load()
+}
+
 // Internal log method
-func (l *sovdevLogger) log(level SovdevLogLevel, functionName, message, peerService string, inputJSON, responseJSON interface{}, exception error, traceID, logType string) {
+func (l *Logger) log(ctx context.Context, level SovdevLogLevel, functionName, message, peerService string, inputJSON, responseJSON interface{}, exception error, traceID string, logType SovdevLogType) error {
+	return l.logAttrs(ctx, level, functionName, message, peerService, inputJSON, responseJSON, exception, traceID, logType, nil)
+}
+
+// logAttrs is log with additional custom attributes (see LogAttrs)
+func (l *Logger) logAttrs(ctx context.Context, level SovdevLogLevel, functionName, message, peerService string, inputJSON, responseJSON interface{}, exception error, traceID string, logType SovdevLogType, attributes map[string]interface{}) error {
+	// Validate required fields
+	requestedLevel := level
+	level, functionName, validationWarning, err := validateEntry(l.config.StrictValidation, level, l.DefaultLevel(), functionName, logType, l.config.LogTypes)
+	if err != nil {
+		return err
+	}
+	if requestedLevel != "" && requestedLevel != level && l.invalidLevelReported.CompareAndSwap(false, true) {
+		fmt.Printf("⚠️  Invalid log level %q logged as %s (see validation_warning; reported once)\n", requestedLevel, level)
+	}
+
+	if !l.enabled(level) {
+		return nil
+	}
+
 	startTime := time.Now()
 
+	// No output takes entries of this level and log type (e.g. OTLPMinLevel and
+	// the other outputs off or above it): skip building the entry, which saves
+	// the payload scrubbing and ID generation, but still count it
+	if !l.anySinkWants(level, logType) {
+		l.recordOperation(ctx, level, l.peerServiceFor(ctx, peerService), logType, tenantIDFromContext(ctx), startTime)
+		return nil
+	}
+
 	// Generate IDs
-	eventID := uuid.New().String()
+	eventID := l.ids.NewID()
+	if traceID != "" {
+		// Caller-supplied IDs must be W3C trace IDs; anything else is replaced
+		normalized, ok := normalizeTraceID(traceID)
+		if !ok {
+			if validationWarning != "" {
+				validationWarning += "; "
+			}
+			invalid := scrubString(traceID)
+			if len(invalid) > 64 {
+				invalid = truncateUTF8(invalid, 64) + "..."
+			}
+			validationWarning += fmt.Sprintf("invalid trace_id %q replaced with a generated one", invalid)
+		}
+		traceID = normalized
+	}
 	if traceID == "" {
 		traceID = SovdevGenerateTraceID()
 	}
 
 	// Resolve peer service
-	resolvedPeerService := l.resolvePeerService(peerService)
+	resolvedPeerService := l.peerServiceFor(ctx, peerService)
+
+	// Scrub secrets (e.g. tokens in URL query strings) and the enabled personal
+	// data categories from message and payloads
+	message = l.pii.redactString(scrubString(message))
+	omitted := omittedPayloadsFromContext(ctx)
+	if omitted.input {
+		inputJSON = nil
+	} else {
+		inputJSON = l.pii.redactPayload(mergeContextFields(ctx, scrubPayload(inputJSON)))
+	}
+	if omitted.response {
+		responseJSON = nil
+	} else {
+		responseJSON = l.pii.redactPayload(scrubPayload(responseJSON))
+	}
+	if names := omitted.names(); names != "" {
+		withMarker := make(map[string]interface{}, len(attributes)+1)
+		for k, v := range attributes {
+			withMarker[k] = v
+		}
+		withMarker["payloads_omitted"] = names
+		attributes = withMarker
+	}
+	if exception != nil {
+		attributes = errorAttributes(exception, attributes)
+	}
+	attributes = l.withGlobalAttributes(attributes)
+	var scrubbedAttributes map[string]interface{}
+	if len(attributes) > 0 {
+		scrubbedAttributes, _ = l.pii.redactPayload(scrubPayload(attributes)).(map[string]interface{})
+	}
+
+	// Encode the payloads once; formatters embed the bytes and OTLP sends them as is
+	inputJSON = encodePayload(inputJSON)
+	responseJSON = encodePayload(responseJSON)
 
 	// Process exception
-	var exceptionType, exceptionMessage, exceptionStacktrace string
+	var exceptionType, exceptionCode, exceptionMessage, exceptionStacktrace string
+	var exceptionChain []ExceptionLink
 	if exception != nil {
 		exceptionType = "Error"
-		exceptionMessage = exception.Error()
-		exceptionStacktrace = limitStackTrace(removeCredentials(fmt.Sprintf("%+v", exception)), 350)
+		exceptionCode = exceptionCodeOf(exception)
+		// Error strings often embed connection details; redact them like the stack trace
+		exceptionMessage = l.pii.redactString(scrubString(removeCredentials(exception.Error())))
+		exceptionStacktrace = limitStackTrace(l.pii.redactString(removeCredentials(fmt.Sprintf("%+v", exception))), 350)
+		if l.includeErrorChain {
+			exceptionChain = errorChain(exception, func(message string) string {
+				return l.pii.redactString(scrubString(removeCredentials(message)))
+			})
+		}
 	}
 
 	// Get span context if available
 	spanID := ""
-	ctx := context.Background()
 	span := apitrace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		traceID = span.SpanContext().TraceID().String()
 		spanID = span.SpanContext().SpanID().String()
 	}
 
+	tenantID := tenantIDFromContext(ctx)
+
+	// Source location of the calling code (opt-in, costs a stack walk)
+	var callerFile string
+	var callerLine int
+	if l.config.IncludeCaller {
+		callerFile, callerLine = callerLocation()
+	}
+
+	// Request-scoped correlation ID, falling back to the process session
+	correlationID := correlationIDFromContext(ctx)
+	if correlationID == "" {
+		correlationID = l.sessionID
+	}
+
 	// Create log entry
 	entry := StructuredLogEntry{
-		Timestamp:           time.Now().UTC().Format(time.RFC3339Nano),
+		Timestamp:           entryTime(ctx).UTC().Format(time.RFC3339Nano),
+		SchemaVersion:       LogSchemaVersion,
 		Level:               string(level),
 		ServiceName:         l.serviceName,
 		ServiceVersion:      l.serviceVersion,
 		SessionID:           l.sessionID,
+		CorrelationID:       correlationID,
+		TenantID:            tenantID,
 		PeerService:         resolvedPeerService,
 		FunctionName:        functionName,
 		Message:             message,
 		TraceID:             traceID,
 		SpanID:              spanID,
 		EventID:             eventID,
+		CallerFile:          callerFile,
+		CallerLine:          callerLine,
 		LogType:             logType,
 		InputJSON:           inputJSON,
 		ResponseJSON:        responseJSON,
+		Attributes:          scrubbedAttributes,
 		ExceptionType:       exceptionType,
+		ExceptionCode:       exceptionCode,
 		ExceptionMessage:    exceptionMessage,
 		ExceptionStacktrace: exceptionStacktrace,
+		ExceptionChain:      exceptionChain,
+		ValidationWarning:   validationWarning,
 	}
 
-	// Write to outputs
-	l.writeToOutputs(level, entry)
+	// Write to outputs, unless this repeats an entry already written in the dedup window.
+	// Audit entries are never collapsed: each one is a separate event in the chain.
+	if l.dedup == nil || logType == SOVDEV_LOGTYPES.AUDIT || !l.dedup.suppress(level, entry) {
+		l.writeToOutputs(ctx, level, entry)
+		l.flushAfter(level)
+	}
 
-	// Record metrics with proper attributes (matching TypeScript labels)
-	if operationCounter != nil {
-		// Create metric attributes matching TypeScript implementation
-		attrs := metric.WithAttributes(
-			semconv.ServiceName(l.serviceName),
-			semconv.ServiceVersion(l.serviceVersion),
-			attribute.String("peer_service", resolvedPeerService),
-			attribute.String("log_type", logType),
-			attribute.String("log_level", string(level)),
-		)
+	// Metrics count every occurrence, including entries suppressed by dedup
+	l.recordOperation(ctx, level, resolvedPeerService, logType, tenantID, startTime)
 
-		operationCounter.Add(ctx, 1, attrs)
-		if level == SOVDEV_LOGLEVELS.ERROR || level == SOVDEV_LOGLEVELS.FATAL {
-			errorCounter.Add(ctx, 1, attrs)
-		}
-		// Record duration in milliseconds (matching TypeScript)
-		duration := float64(time.Since(startTime).Milliseconds())
-		operationDuration.Record(ctx, duration, attrs)
+	return nil
+}
+
+// recordOperation records the operation metrics for one log call that started at startTime
+func (l *Logger) recordOperation(ctx context.Context, level SovdevLogLevel, resolvedPeerService string, logType SovdevLogType, tenantID string, startTime time.Time) {
+	// Record metrics with proper attributes (matching TypeScript labels)
+	if l.operationCounter != nil {
+		attrs := l.countOperation(ctx, level, resolvedPeerService, logType, tenantID)
+		// Record duration in milliseconds (matching TypeScript), keeping the fraction
+		// so sub-millisecond emits land in the small buckets instead of all in 0
+		duration := float64(time.Since(startTime)) / float64(time.Millisecond)
+		l.operationDuration.Record(ctx, duration, attrs)
 	}
 }
 
-func (l *sovdevLogger) writeToOutputs(level SovdevLogLevel, entry StructuredLogEntry) {
-	// Marshal to JSON
-	jsonBytes, err := json.Marshal(entry)
-	if err != nil {
-		fmt.Printf("❌ Failed to marshal log entry: %v\n", err)
-		return
+// countOperation increments the operation (and for ERROR/FATAL the error) counter
+// and returns the attributes used, so the caller can record the duration with them
+func (l *Logger) countOperation(ctx context.Context, level SovdevLogLevel, resolvedPeerService string, logType SovdevLogType, tenantID string) metric.MeasurementOption {
+	// Create metric attributes matching TypeScript implementation
+	metricAttrs := []attribute.KeyValue{
+		attribute.String("peer_service", l.metricPeerService(resolvedPeerService)),
+		attribute.String("log_type", l.metricLogType(logType)),
+		attribute.String("log_level", string(level)),
+	}
+	if tenantID != "" && l.config.TenantMetricLabels {
+		metricAttrs = append(metricAttrs, attribute.String("tenant", tenantID))
 	}
+	attrs := l.metricAttributes(metricAttrs...)
 
-	// File output
-	if l.logToFile && l.fileLogger != nil {
-		l.fileLogger.Println(string(jsonBytes))
+	l.operationCounter.Add(ctx, 1, attrs)
+	if level == SOVDEV_LOGLEVELS.ERROR || level == SOVDEV_LOGLEVELS.FATAL {
+		l.errorCounter.Add(ctx, 1, attrs)
+	}
+	return attrs
+}
 
-		// Error file
-		if (level == SOVDEV_LOGLEVELS.ERROR || level == SOVDEV_LOGLEVELS.FATAL) && l.errorLogger != nil {
-			l.errorLogger.Println(string(jsonBytes))
-		}
+// writeToOutputs hands entry to every sink while holding writeMu, so concurrent
+// entries reach all outputs in the same order (dev.log, OTLP and the HTTP sinks
+// agree on what came first). The cost is that concurrent log calls wait for each
+// other's writes; sinks are non-blocking or write a single line, so the lock is
+// held for microseconds, or for a disk flush per entry with FileSyncEachWrite.
+func (l *Logger) writeToOutputs(ctx context.Context, level SovdevLogLevel, entry StructuredLogEntry) {
+	l.writeSinks(ctx, entry)
+
+	// Logged only now: logging while holding writeMu would deadlock
+	if l.truncationPending.CompareAndSwap(true, false) {
+		l.reportTruncation()
 	}
+}
 
-	// Console output
-	if l.logToConsole && l.consoleLogger != nil {
-		l.consoleLogger.Println(string(jsonBytes))
+func (l *Logger) writeSinks(ctx context.Context, entry StructuredLogEntry) {
+	l.writeMu.Lock()
+	defer l.writeMu.Unlock()
+
+	if l.recent != nil {
+		l.recent.add(entry)
 	}
 
-	// OTLP output
-	if l.otlpLogger != nil {
-		l.writeToOTLP(level, entry)
+	for _, s := range l.sinks {
+		l.writeSink(ctx, s, entry)
 	}
 }
 
-func (l *sovdevLogger) writeToOTLP(level SovdevLogLevel, entry StructuredLogEntry) {
-	ctx := context.Background()
+// reportTruncation logs, once per run, that OTLP attributes were truncated
+func (l *Logger) reportTruncation() {
+	l.log(context.Background(), SOVDEV_LOGLEVELS.DEBUG, "writeToOTLP",
+		"OTLP attributes truncated to respect attribute limits (reported once per run)",
+		l.selfPeerName,
+		map[string]interface{}{
+			"max_attribute_value_length": l.attributeValueLengthLimit(),
+			"max_attribute_count":        l.attributeCountLimit(),
+		},
+		nil, nil, "", SOVDEV_LOGTYPES.TRANSACTION)
+}
+
+func (l *Logger) writeToOTLP(ctx context.Context, level SovdevLogLevel, entry StructuredLogEntry) {
+	// The SDK takes the record's native TraceID/SpanID from the span context in ctx.
+	// Without an active span, bind the entry's own trace_id/span_id so backends
+	// still link the record to its trace.
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if !apitrace.SpanContextFromContext(ctx).IsValid() {
+		ctx = contextWithEntryTrace(ctx, entry)
+	}
 
 	var logLevel otlog.Severity
 	switch level {
@@ -650,60 +1818,142 @@ func (l *sovdevLogger) writeToOTLP(level SovdevLogLevel, entry StructuredLogEntr
 	}
 
 	record := otlog.Record{}
-	record.SetTimestamp(time.Now())
+	// Use the entry's own time so replayed and deduplicated entries keep theirs
+	timestamp, err := time.Parse(time.RFC3339Nano, entry.Timestamp)
+	if err != nil {
+		timestamp = time.Now()
+	}
+	record.SetTimestamp(timestamp)
 	record.SetSeverity(logLevel)
 	record.SetSeverityText(mapToSeverityText(level))
 	record.SetBody(otlog.StringValue(entry.Message))
+	if l.otlpMapBody {
+		if body, err := entryBody(entry); err == nil {
+			record.SetBody(body)
+		}
+	}
 
-	// Add attributes
-	record.AddAttributes(
+	// Collect attributes
+	attrs := []otlog.KeyValue{
+		otlog.String("schema_version", entry.SchemaVersion),
 		otlog.String("service_name", entry.ServiceName),
 		otlog.String("service_version", entry.ServiceVersion),
 		otlog.String("session_id", entry.SessionID),
+		otlog.String("correlation_id", entry.CorrelationID),
 		otlog.String("peer_service", entry.PeerService),
 		otlog.String("function_name", entry.FunctionName),
 		otlog.String("trace_id", entry.TraceID),
 		otlog.String("event_id", entry.EventID),
-		otlog.String("log_type", entry.LogType),
-	)
+		otlog.String("log_type", string(entry.LogType)),
+	}
 
 	if entry.SpanID != "" {
-		record.AddAttributes(otlog.String("span_id", entry.SpanID))
+		attrs = append(attrs, otlog.String("span_id", entry.SpanID))
+	}
+
+	if entry.TenantID != "" {
+		attrs = append(attrs, otlog.String("tenant_id", entry.TenantID))
+	}
+
+	if entry.CallerFile != "" {
+		attrs = append(attrs,
+			otlog.String("caller_file", entry.CallerFile),
+			otlog.Int("caller_line", entry.CallerLine),
+		)
 	}
 
+	var inputBytes, responseBytes []byte
 	if entry.InputJSON != nil {
-		if jsonBytes, err := json.Marshal(entry.InputJSON); err == nil {
-			record.AddAttributes(otlog.String("input_json", string(jsonBytes)))
+		if jsonBytes, err := payloadJSON(entry.InputJSON); err == nil {
+			attrs = append(attrs, otlog.String("input_json", string(jsonBytes)))
+			inputBytes = jsonBytes
 		}
 	}
 
 	if entry.ResponseJSON != nil {
-		if jsonBytes, err := json.Marshal(entry.ResponseJSON); err == nil {
-			record.AddAttributes(otlog.String("response_json", string(jsonBytes)))
+		if jsonBytes, err := payloadJSON(entry.ResponseJSON); err == nil {
+			attrs = append(attrs, otlog.String("response_json", string(jsonBytes)))
+			responseBytes = jsonBytes
 		}
 	}
 
 	if entry.ExceptionType != "" {
-		record.AddAttributes(
+		attrs = append(attrs,
 			otlog.String("exception_type", entry.ExceptionType),
 			otlog.String("exception_message", entry.ExceptionMessage),
 			otlog.String("exception_stacktrace", entry.ExceptionStacktrace),
 		)
+		if entry.ExceptionCode != "" {
+			attrs = append(attrs, otlog.String("exception_code", entry.ExceptionCode))
+		}
+		if len(entry.ExceptionChain) > 0 {
+			if chainJSON, err := marshalJSON(entry.ExceptionChain); err == nil {
+				attrs = append(attrs, otlog.String("exception_chain", string(chainJSON)))
+			}
+		}
+	}
+
+	if entry.ValidationWarning != "" {
+		attrs = append(attrs, otlog.String("validation_warning", entry.ValidationWarning))
+	}
+
+	if entry.Repeated > 0 {
+		attrs = append(attrs, otlog.Int("repeated", entry.Repeated))
+	}
+
+	// Custom attributes from LogAttrs, after all standard ones so none can be overwritten
+	if len(entry.Attributes) > 0 {
+		attrs = append(attrs, customAttributes(entry.Attributes, attrs)...)
+	}
+
+	// Flattened payload fields, capped by both the per-payload and the record-wide limit
+	countLimit := l.attributeCountLimit()
+	if l.config.FlattenPayloads {
+		maxKeys := l.config.MaxFlattenedAttributes
+		if maxKeys <= 0 {
+			maxKeys = defaultMaxFlattenedAttributes
+		}
+		if inputBytes != nil {
+			attrs = append(attrs, flattenPayload("input", inputBytes, min(maxKeys, countLimit-len(attrs)))...)
+		}
+		if responseBytes != nil {
+			attrs = append(attrs, flattenPayload("response", responseBytes, min(maxKeys, countLimit-len(attrs)))...)
+		}
 	}
 
+	// Enforce limits ourselves so collectors never silently drop oversized attributes
+	attrs, truncated := enforceAttributeLimits(attrs, l.attributeValueLengthLimit(), countLimit)
+	record.AddAttributes(attrs...)
+
 	l.otlpLogger.Emit(ctx, record)
-}
 
-func (l *sovdevLogger) resolvePeerService(friendlyName string) string {
-	if friendlyName == "" || friendlyName == "INTERNAL" {
-		return l.serviceName
+	// Called under writeMu (via otlpSink), so writeToOutputs reports it afterwards
+	if truncated && l.truncationReported.CompareAndSwap(false, true) {
+		l.truncationPending.Store(true)
 	}
+}
 
-	if systemID, ok := l.peerServiceMap[friendlyName]; ok {
-		return systemID
+// contextWithEntryTrace returns ctx carrying a remote span context built from the
+// entry's trace_id and span_id; ctx is returned unchanged if trace_id is not a valid ID
+func contextWithEntryTrace(ctx context.Context, entry StructuredLogEntry) context.Context {
+	traceID, err := apitrace.TraceIDFromHex(entry.TraceID)
+	if err != nil {
+		return ctx
 	}
+	spanID, _ := apitrace.SpanIDFromHex(entry.SpanID) // Zero when absent
+	return apitrace.ContextWithSpanContext(ctx, apitrace.NewSpanContext(apitrace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+		Remote:  true,
+	}))
+}
+
+// resolvePeerService maps a friendly name to its system ID
+func (l *Logger) resolvePeerService(friendlyName string) string {
+	l.peerMu.RLock()
+	defer l.peerMu.RUnlock()
 
-	return friendlyName
+	return resolvePeerName(friendlyName, l.selfPeerName, l.serviceName, l.peerServiceMap)
 }
 
 // Utility functions
@@ -714,22 +1964,85 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
-func removeCredentials(stack string) string {
-	patterns := []struct {
-		regex       *regexp.Regexp
-		replacement string
-	}{
-		{regexp.MustCompile(`(?i)Authorization[:\s]+[^\s,}]+`), "Authorization: [REDACTED]"},
-		{regexp.MustCompile(`(?i)Bearer\s+[A-Za-z0-9\-._~+/]+=*`), "Bearer [REDACTED]"},
-		{regexp.MustCompile(`(?i)api[-_]?key[:\s=]+[^\s,}]+`), "api-key: [REDACTED]"},
-		{regexp.MustCompile(`(?i)password[:\s=]+[^\s,}]+`), "password: [REDACTED]"},
-		{regexp.MustCompile(`[A-Za-z0-9\-_]+\.[A-Za-z0-9\-_]+\.[A-Za-z0-9\-_]+`), "[REDACTED-JWT]"},
-		{regexp.MustCompile(`(?i)session[-_]?id[:\s=]+[^\s,}]+`), "session-id: [REDACTED]"},
-		{regexp.MustCompile(`(?i)Cookie[:\s]+[^\r\n]+`), "Cookie: [REDACTED]"},
+// getEnvBool parses a boolean environment variable (1/0, t/f, true/false, yes/no,
+// on/off, any case); unset or unparseable values use defaultValue, the latter with a warning
+func getEnvBool(key string, defaultValue bool) bool {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return defaultValue
+	}
+	switch strings.ToLower(value) {
+	case "yes", "y", "on":
+		return true
+	case "no", "n", "off":
+		return false
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		fmt.Printf("⚠️  Invalid boolean %s=%q, using %t\n", key, value, defaultValue)
+		return defaultValue
 	}
+	return parsed
+}
 
+// getEnvDuration parses a duration environment variable (e.g. "500ms", "2m");
+// unset or unparseable values use defaultValue, the latter with a warning
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed <= 0 {
+		fmt.Printf("⚠️  Invalid duration %s=%q, using %s\n", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// getEnvInt parses a non-negative integer environment variable; unset or
+// unparseable values use defaultValue, the latter with a warning
+func getEnvInt(key string, defaultValue int) int {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 0 {
+		fmt.Printf("⚠️  Invalid number %s=%q, using %d\n", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// positiveOr returns value if set (> 0), otherwise fallback
+func positiveOr(value, fallback int) int {
+	if value > 0 {
+		return value
+	}
+	return fallback
+}
+
+// credentialPatterns match credentials that commonly end up in error strings
+var credentialPatterns = []struct {
+	regex       *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`(?i)Authorization[:\s]+(?:(?:Bearer|Basic|Token|Splunk|ApiKey)\s+)?[^\s,}]+`), "Authorization: [REDACTED]"},
+	{regexp.MustCompile(`(?i)Bearer\s+[A-Za-z0-9\-._~+/]+=*`), "Bearer [REDACTED]"},
+	{regexp.MustCompile(`(?i)api[-_]?key[:\s=]+[^\s,}]+`), "api-key: [REDACTED]"},
+	{regexp.MustCompile(`(?i)password[:\s=]+[^\s,}]+`), "password: [REDACTED]"},
+	// JWTs start with a base64url-encoded JSON header ("eyJ"); matching any
+	// dotted triple would also redact IP addresses and host names
+	{regexp.MustCompile(`eyJ[A-Za-z0-9\-_]+\.[A-Za-z0-9\-_]+\.[A-Za-z0-9\-_]+`), "[REDACTED-JWT]"},
+	{regexp.MustCompile(`(?i)session[-_]?id[:\s=]+[^\s,}]+`), "session-id: [REDACTED]"},
+	{regexp.MustCompile(`(?i)Cookie[:\s]+[^\r\n]+`), "Cookie: [REDACTED]"},
+}
+
+// removeCredentials masks credentials in an exception message or stack trace
+func removeCredentials(stack string) string {
 	result := stack
-	for _, p := range patterns {
+	for _, p := range credentialPatterns {
 		result = p.regex.ReplaceAllString(result, p.replacement)
 	}
 	return result
@@ -739,5 +2052,5 @@ func limitStackTrace(stack string, maxLength int) string {
 	if len(stack) <= maxLength {
 		return stack
 	}
-	return stack[:maxLength] + "... (truncated)"
+	return truncateUTF8(stack, maxLength) + "... (truncated)"
 }
@@ -61,3 +61,66 @@ func mapToSeverityText(level SovdevLogLevel) string {
 		return "INFO"
 	}
 }
+
+// isValidLevel reports whether level is one of SOVDEV_LOGLEVELS
+func isValidLevel(level SovdevLogLevel) bool {
+	switch level {
+	case SOVDEV_LOGLEVELS.TRACE, SOVDEV_LOGLEVELS.DEBUG, SOVDEV_LOGLEVELS.INFO,
+		SOVDEV_LOGLEVELS.WARN, SOVDEV_LOGLEVELS.ERROR, SOVDEV_LOGLEVELS.FATAL:
+		return true
+	default:
+		return false
+	}
+}
+
+// SovdevSetMinLevel changes the minimum level at runtime; entries below it are dropped
+func SovdevSetMinLevel(level SovdevLogLevel) {
+	logger := defaultLogger()
+	if logger == nil {
+		return
+	}
+	logger.SetMinLevel(level)
+}
+
+// SovdevLevelEnabled reports whether an entry at level would currently be emitted
+func SovdevLevelEnabled(level SovdevLogLevel) bool {
+	logger := defaultLogger()
+	return logger != nil && logger.enabled(level)
+}
+
+// SetMinLevel changes the minimum level of this logger at runtime
+func (l *Logger) SetMinLevel(level SovdevLogLevel) {
+	if !isValidLevel(level) {
+		return
+	}
+	l.minSeverity.Store(int32(mapToSeverityNumber(level)))
+}
+
+// SovdevSetDefaultLevel changes the level used for entries logged with an empty
+// level, e.g. by wrapper helpers that leave the choice to the service, or with an
+// invalid one (which also gets a validation_warning). The default is INFO.
+func SovdevSetDefaultLevel(level SovdevLogLevel) {
+	logger := defaultLogger()
+	if logger == nil {
+		return
+	}
+	logger.SetDefaultLevel(level)
+}
+
+// SetDefaultLevel changes the default level of this logger at runtime (see SovdevSetDefaultLevel)
+func (l *Logger) SetDefaultLevel(level SovdevLogLevel) {
+	if !isValidLevel(level) {
+		return
+	}
+	l.defaultSeverity.Store(int32(mapToSeverityNumber(level)))
+}
+
+// DefaultLevel returns the level used for entries logged with an empty or invalid level
+func (l *Logger) DefaultLevel() SovdevLogLevel {
+	return severityToLevel(l.defaultSeverity.Load())
+}
+
+// LevelEnabled reports whether an entry at level would currently be emitted by this logger
+func (l *Logger) LevelEnabled(level SovdevLogLevel) bool {
+	return l.enabled(level)
+}
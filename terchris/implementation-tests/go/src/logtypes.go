@@ -0,0 +1,92 @@
+package sovdevlogger
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+)
+
+// SovdevLogType is the log_type of an entry: lowercase words joined by dots,
+// e.g. "job.status". Use SOVDEV_LOGTYPES, or register own types in Config.LogTypes.
+type SovdevLogType string
+
+// SOVDEV_LOGTYPES defines the standard log_type values
+// TRANSACTION, JOB_STATUS and JOB_PROGRESS are written by SovdevLog and the job
+// helpers; AUDIT and SECURITY are for SovdevLogTyped so those entries can be
+// routed separately (e.g. to long-term retention). "otel.internal" is reserved
+// for the logger's own OTEL SDK diagnostics and cannot be logged by callers.
+var SOVDEV_LOGTYPES = struct {
+	TRANSACTION  SovdevLogType
+	JOB_STATUS   SovdevLogType
+	JOB_PROGRESS SovdevLogType
+	AUDIT        SovdevLogType
+	SECURITY     SovdevLogType
+}{
+	TRANSACTION:  "transaction",
+	JOB_STATUS:   "job.status",
+	JOB_PROGRESS: "job.progress",
+	AUDIT:        "audit",
+	SECURITY:     "security",
+}
+
+// logTypePattern is the shape every log_type must have: lowercase words joined by dots
+var logTypePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*(\.[a-z][a-z0-9_]*)*$`)
+
+// isKnownLogType reports whether logType is a standard type, the reserved
+// internal type, or listed in extra (Config.LogTypes)
+func isKnownLogType(logType SovdevLogType, extra []SovdevLogType) bool {
+	switch logType {
+	case SOVDEV_LOGTYPES.TRANSACTION, SOVDEV_LOGTYPES.JOB_STATUS, SOVDEV_LOGTYPES.JOB_PROGRESS,
+		SOVDEV_LOGTYPES.AUDIT, SOVDEV_LOGTYPES.SECURITY, logTypeOTELInternal:
+		return true
+	}
+	for _, t := range extra {
+		if t == logType {
+			return true
+		}
+	}
+	return false
+}
+
+// metricLogType returns the log_type metric attribute: unchanged, or "other"
+// with BoundMetricLabels for types isKnownLogType does not accept
+func (l *Logger) metricLogType(logType SovdevLogType) string {
+	if !l.boundMetricLabels || isKnownLogType(logType, l.config.LogTypes) {
+		return string(logType)
+	}
+	return metricOtherValue
+}
+
+// SovdevLogTyped logs an entry with an explicit log_type, e.g. SOVDEV_LOGTYPES.AUDIT.
+// Types outside SOVDEV_LOGTYPES and Config.LogTypes are logged with a
+// validation_warning, or rejected when Config.StrictValidation is set; malformed
+// types (not lowercase dot-separated words) and the reserved "otel.internal" are
+// always rejected.
+func SovdevLogTyped(logType SovdevLogType, level SovdevLogLevel, functionName, message, peerService string, inputJSON, responseJSON interface{}, exception error, traceID string) {
+	logger := defaultLogger()
+	if logger == nil {
+		bufferPreInit(func(ctx context.Context, l *Logger) error {
+			return l.logTyped(ctx, logType, level, functionName, message, peerService, inputJSON, responseJSON, exception, traceID)
+		})
+		return
+	}
+
+	if err := logger.LogTyped(logType, level, functionName, message, peerService, inputJSON, responseJSON, exception, traceID); err != nil {
+		fmt.Printf("⚠️  Log entry rejected: %v\n", err)
+	}
+}
+
+// LogTyped logs an entry with an explicit log_type (see SovdevLogTyped)
+func (l *Logger) LogTyped(logType SovdevLogType, level SovdevLogLevel, functionName, message, peerService string, inputJSON, responseJSON interface{}, exception error, traceID string) error {
+	return l.logTyped(context.Background(), logType, level, functionName, message, peerService, inputJSON, responseJSON, exception, traceID)
+}
+
+func (l *Logger) logTyped(ctx context.Context, logType SovdevLogType, level SovdevLogLevel, functionName, message, peerService string, inputJSON, responseJSON interface{}, exception error, traceID string) error {
+	if logType == logTypeOTELInternal {
+		return fmt.Errorf("%w: %q is reserved", ErrInvalidLogType, logType)
+	}
+	if !logTypePattern.MatchString(string(logType)) {
+		return fmt.Errorf("%w: %q", ErrInvalidLogType, logType)
+	}
+	return l.log(ctx, level, functionName, message, peerService, inputJSON, responseJSON, exception, traceID, logType)
+}
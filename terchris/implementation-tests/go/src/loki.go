@@ -0,0 +1,203 @@
+package sovdevlogger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// lokiQueueSize bounds entries waiting to be sent; newer entries are dropped when full
+	lokiQueueSize = 10000
+	// lokiBatchSize is the maximum number of entries per push request
+	lokiBatchSize = 500
+	// lokiBatchInterval sends partial batches at least this often
+	lokiBatchInterval = 2 * time.Second
+	// lokiMaxAttempts includes the first try; 429, 5xx and network errors are retried
+	lokiMaxAttempts = 3
+	// lokiMaxBackoff caps the wait between attempts, including Retry-After
+	lokiMaxBackoff = 30 * time.Second
+	// lokiPushPath is appended to an endpoint given without a path
+	lokiPushPath = "/loki/api/v1/push"
+)
+
+// LokiSink ships entries to Grafana Loki's push API. Each entry's JSON (as in
+// dev.log) is the log line; the stream labels are service_name, level,
+// peer_service and log_type only, so the number of streams stays small.
+// Per-entry values such as trace_id stay in the line, where LogQL's json
+// parser reaches them. Entries are queued (bounded) and pushed in batches by a
+// background goroutine; 429, 5xx and network errors are retried with backoff.
+type LokiSink struct {
+	endpoint string
+	username string
+	password string
+	client   *http.Client
+	throttle *selfLogThrottle
+	queue    *batchQueue
+	// labelValue maps peer_service and log_type label values, e.g. to "other"
+	// with Config.BoundMetricLabels; nil keeps them
+	labelValue func(label, value string) string
+}
+
+// NewLokiSink creates a sink pushing to endpoint, e.g. "http://loki:3100"
+// (the push path /loki/api/v1/push is added when the endpoint has no path).
+// username and password, if set, are sent as basic auth.
+func NewLokiSink(endpoint, username, password string) (*LokiSink, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid Loki endpoint %q", endpoint)
+	}
+	if strings.Trim(u.Path, "/") == "" {
+		u.Path = lokiPushPath
+	}
+
+	s := &LokiSink{
+		endpoint: u.String(),
+		username: username,
+		password: password,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		throttle: newSelfLogThrottle(selfLogInterval),
+	}
+	s.queue = newBatchQueue(lokiQueueSize, lokiBatchSize, lokiBatchInterval, s.send)
+	return s, nil
+}
+
+// Write queues an entry without blocking
+func (s *LokiSink) Write(entry StructuredLogEntry) error {
+	return s.queue.add(entry)
+}
+
+// Flush sends all queued entries, waiting until done or ctx expires
+func (s *LokiSink) Flush(ctx context.Context) error {
+	return s.queue.flush(ctx)
+}
+
+// Close sends the remaining entries and stops the sink. Later writes return ErrSinkClosed.
+func (s *LokiSink) Close() error {
+	return s.CloseContext(context.Background())
+}
+
+// CloseContext is Close bounded by ctx
+func (s *LokiSink) CloseContext(ctx context.Context) error {
+	return s.queue.close(ctx)
+}
+
+// lokiStream is one stream in a push request
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// send pushes one batch, retrying 429, 5xx responses and network errors
+func (s *LokiSink) send(batch []StructuredLogEntry) {
+	payload, err := s.encode(batch)
+	if err != nil {
+		if ok, suppressed := s.throttle.allow("encode"); ok {
+			fmt.Printf("⚠️  Loki: dropped %d entries: %v (%d similar failures suppressed)\n", len(batch), err, suppressed)
+		}
+		return
+	}
+
+	var wait time.Duration
+	for attempt := 0; attempt < lokiMaxAttempts; attempt++ {
+		if attempt > 0 {
+			if wait <= 0 {
+				wait = time.Duration(attempt) * 500 * time.Millisecond
+			}
+			time.Sleep(min(wait, lokiMaxBackoff))
+		}
+		var retry bool
+		retry, wait, err = s.post(payload)
+		if err == nil || !retry {
+			break
+		}
+	}
+
+	if err != nil {
+		if ok, suppressed := s.throttle.allow("send"); ok {
+			fmt.Printf("⚠️  Loki: dropped %d entries: %v (%d similar failures suppressed)\n", len(batch), err, suppressed)
+		}
+	}
+}
+
+// encode builds the push request for a batch, one stream per label set
+func (s *LokiSink) encode(batch []StructuredLogEntry) ([]byte, error) {
+	var streams []*lokiStream
+	byLabels := make(map[string]*lokiStream)
+	for _, entry := range batch {
+		line, err := JSONFormatter{}.Format(entry)
+		if err != nil {
+			return nil, err
+		}
+		ts, err := time.Parse(time.RFC3339Nano, entry.Timestamp)
+		if err != nil {
+			ts = time.Now()
+		}
+
+		labels := s.labels(entry)
+		key := labels["service_name"] + "\x00" + labels["level"] + "\x00" + labels["peer_service"] + "\x00" + labels["log_type"]
+		stream, ok := byLabels[key]
+		if !ok {
+			stream = &lokiStream{Stream: labels}
+			byLabels[key] = stream
+			streams = append(streams, stream)
+		}
+		stream.Values = append(stream.Values, [2]string{strconv.FormatInt(ts.UnixNano(), 10), string(line)})
+	}
+	return json.Marshal(map[string]interface{}{"streams": streams})
+}
+
+// labels returns the stream labels of entry, leaving out empty values
+func (s *LokiSink) labels(entry StructuredLogEntry) map[string]string {
+	labels := map[string]string{
+		"service_name": entry.ServiceName,
+		"level":        entry.Level,
+		"peer_service": entry.PeerService,
+		"log_type":     string(entry.LogType),
+	}
+	if s.labelValue != nil {
+		labels["peer_service"] = s.labelValue("peer_service", entry.PeerService)
+		labels["log_type"] = s.labelValue("log_type", string(entry.LogType))
+	}
+	for name, value := range labels {
+		if value == "" {
+			delete(labels, name)
+		}
+	}
+	return labels
+}
+
+// post sends payload once and reports whether a failure is worth retrying,
+// with the server's Retry-After
+func (s *LokiSink) post(payload []byte) (bool, time.Duration, error) {
+	req, err := http.NewRequest(http.MethodPost, s.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return false, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.username != "" || s.password != "" {
+		req.SetBasicAuth(s.username, s.password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, 0, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, retryAfter(resp), fmt.Errorf("push returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	case resp.StatusCode >= 300:
+		return false, 0, fmt.Errorf("push returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return false, 0, nil
+}
@@ -0,0 +1,186 @@
+package sovdevlogger
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+// temporalitySelectors are the OTLP metric temporalities accepted by
+// Config.MetricsTemporality, named as in OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE
+var temporalitySelectors = map[string]sdkmetric.TemporalitySelector{
+	"cumulative": func(sdkmetric.InstrumentKind) metricdata.Temporality {
+		return metricdata.CumulativeTemporality
+	},
+	"delta": func(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+		switch kind {
+		case sdkmetric.InstrumentKindCounter, sdkmetric.InstrumentKindHistogram, sdkmetric.InstrumentKindObservableCounter:
+			return metricdata.DeltaTemporality
+		}
+		return metricdata.CumulativeTemporality
+	},
+	"lowmemory": func(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+		switch kind {
+		case sdkmetric.InstrumentKindCounter, sdkmetric.InstrumentKindHistogram:
+			return metricdata.DeltaTemporality
+		}
+		return metricdata.CumulativeTemporality
+	},
+}
+
+// SovdevMetricsHandler returns an http.Handler serving the sovdev.* metrics in
+// Prometheus text format, or OpenMetrics with exemplars when the scraper asks for it.
+// Mount it at /metrics when Config.PrometheusMetrics is set.
+// The handler can be mounted before SovdevInitializeWithConfig runs; until
+// Prometheus metrics are enabled it responds with 404.
+func SovdevMetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger := defaultLogger()
+
+		if logger == nil {
+			http.Error(w, "prometheus metrics not enabled", http.StatusNotFound)
+			return
+		}
+		logger.MetricsHandler().ServeHTTP(w, r)
+	})
+}
+
+// MetricsHandler returns an http.Handler serving this logger's metrics in
+// Prometheus text format, or a 404 handler when Config.PrometheusMetrics is off
+func (l *Logger) MetricsHandler() http.Handler {
+	if l.metricsHandler == nil {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "prometheus metrics not enabled", http.StatusNotFound)
+		})
+	}
+	return l.metricsHandler
+}
+
+// registerServiceUp registers sovdev.service.up, a gauge observed as 1 at every
+// collection while the logger runs, so sum(sovdev_service_up) by
+// (service_version) counts the running instances per version. Unlike the other
+// sovdev metrics it carries service.name, service.version and service.instance.id
+// as attributes: it is one series per instance, and having them as labels makes
+// that count a single-metric query. An instance that stops reporting drops out
+// once the backend marks the series stale.
+func (l *Logger) registerServiceUp() error {
+	attrs := metric.WithAttributes(
+		semconv.ServiceName(l.serviceName),
+		semconv.ServiceVersion(l.serviceVersion),
+		semconv.ServiceInstanceID(l.instanceID),
+	)
+	_, err := l.meter.Int64ObservableGauge("sovdev.service.up",
+		metric.WithDescription("1 while the service instance is running"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(1, attrs)
+			return nil
+		}))
+	return err
+}
+
+// SovdevIncOperation adds one to sovdev.operations.total for a domain operation of
+// the service itself. Use the same attribute keys as log entries (peer_service,
+// log_type, log_level) so dashboards can combine both sources. No-op before SovdevInitialize.
+func SovdevIncOperation(attrs ...attribute.KeyValue) {
+	logger := defaultLogger()
+	if logger == nil {
+		return
+	}
+	logger.IncOperation(attrs...)
+}
+
+// SovdevIncError adds one to sovdev.errors.total (see SovdevIncOperation)
+func SovdevIncError(attrs ...attribute.KeyValue) {
+	logger := defaultLogger()
+	if logger == nil {
+		return
+	}
+	logger.IncError(attrs...)
+}
+
+// SovdevRecordDuration records ms in sovdev.operation.duration (see SovdevIncOperation)
+func SovdevRecordDuration(ms float64, attrs ...attribute.KeyValue) {
+	logger := defaultLogger()
+	if logger == nil {
+		return
+	}
+	logger.RecordDuration(ms, attrs...)
+}
+
+// IncOperation adds one to sovdev.operations.total (see SovdevIncOperation)
+func (l *Logger) IncOperation(attrs ...attribute.KeyValue) {
+	if l.operationCounter != nil {
+		l.operationCounter.Add(context.Background(), 1, l.metricAttributes(attrs...))
+	}
+}
+
+// IncError adds one to sovdev.errors.total (see SovdevIncOperation)
+func (l *Logger) IncError(attrs ...attribute.KeyValue) {
+	if l.errorCounter != nil {
+		l.errorCounter.Add(context.Background(), 1, l.metricAttributes(attrs...))
+	}
+}
+
+// RecordDuration records ms in sovdev.operation.duration (see SovdevIncOperation)
+func (l *Logger) RecordDuration(ms float64, attrs ...attribute.KeyValue) {
+	if l.operationDuration != nil {
+		l.operationDuration.Record(context.Background(), ms, l.metricAttributes(attrs...))
+	}
+}
+
+// metricAttributes returns the measurement attributes for a sovdev metric.
+// service.name and service.version are not among them: they are resource
+// attributes, exported once per batch rather than on every series. Dashboards
+// that filtered on a service_name series label need the resource instead: with
+// an OTLP collector the job label (or resource_to_telemetry_conversion), with
+// SovdevMetricsHandler a join on target_info. Global attributes
+// (SetGlobalAttributes) are added; the metric's own attributes win.
+func (l *Logger) metricAttributes(attrs ...attribute.KeyValue) metric.MeasurementOption {
+	if global := l.globalAttrs.load(); global != nil {
+		attrs = append(append([]attribute.KeyValue{}, global.otel...), attrs...)
+	}
+	return metric.WithAttributes(attrs...)
+}
+
+// SovdevNewCounter creates a counter sharing the logger's resource and export
+// pipeline. Before SovdevInitialize it returns a no-op counter, so package-level
+// instruments can be created in init code without failing.
+func SovdevNewCounter(name, description string) (metric.Int64Counter, error) {
+	logger := defaultLogger()
+	if logger == nil {
+		return noop.Int64Counter{}, nil
+	}
+	return logger.NewCounter(name, description)
+}
+
+// SovdevNewHistogram creates a histogram sharing the logger's resource and export
+// pipeline (no-op before SovdevInitialize, see SovdevNewCounter)
+func SovdevNewHistogram(name, description, unit string) (metric.Float64Histogram, error) {
+	logger := defaultLogger()
+	if logger == nil {
+		return noop.Float64Histogram{}, nil
+	}
+	return logger.NewHistogram(name, description, unit)
+}
+
+// NewCounter creates a counter on this logger's meter (see SovdevNewCounter)
+func (l *Logger) NewCounter(name, description string) (metric.Int64Counter, error) {
+	if l.meter == nil {
+		return noop.Int64Counter{}, nil
+	}
+	return l.meter.Int64Counter(name, metric.WithDescription(description))
+}
+
+// NewHistogram creates a histogram on this logger's meter (see SovdevNewHistogram)
+func (l *Logger) NewHistogram(name, description, unit string) (metric.Float64Histogram, error) {
+	if l.meter == nil {
+		return noop.Float64Histogram{}, nil
+	}
+	return l.meter.Float64Histogram(name, metric.WithDescription(description), metric.WithUnit(unit))
+}
@@ -0,0 +1,113 @@
+package sovdevlogger
+
+import (
+	"errors"
+	"testing"
+
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// exportedMetrics decodes every metrics export the collector received, by metric name
+func exportedMetrics(t *testing.T, c *testCollector) map[string]*metricpb.Metric {
+	t.Helper()
+	metrics := make(map[string]*metricpb.Metric)
+	for _, body := range c.Requests("/v1/metrics") {
+		var req colmetricpb.ExportMetricsServiceRequest
+		if err := proto.Unmarshal(body, &req); err != nil {
+			t.Fatalf("decode metrics export: %v", err)
+		}
+		for _, rm := range req.ResourceMetrics {
+			for _, sm := range rm.ScopeMetrics {
+				for _, m := range sm.Metrics {
+					metrics[m.Name] = m
+				}
+			}
+		}
+	}
+	return metrics
+}
+
+func TestMetricsTemporality(t *testing.T) {
+	cumulative := metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE
+	delta := metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA
+
+	tests := []struct {
+		name   string
+		config string
+		env    string
+		want   metricpb.AggregationTemporality
+	}{
+		{"default", "", "", cumulative},
+		{"config delta", "delta", "", delta},
+		{"env delta", "", "delta", delta},
+		{"config wins over env", "cumulative", "delta", cumulative},
+		{"env lowmemory", "", "LowMemory", delta},
+		{"unknown falls back to cumulative", "sometimes", "", cumulative},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE", tt.env)
+			l, _, collector := newCollectorLogger(t, Config{MetricsTemporality: tt.config})
+
+			if err := l.Log(SOVDEV_LOGLEVELS.INFO, "TestTemporality", "Counted", "", nil, nil, nil, ""); err != nil {
+				t.Fatalf("Log: %v", err)
+			}
+			if err := l.Flush(); err != nil {
+				t.Fatalf("Flush: %v", err)
+			}
+
+			m := exportedMetrics(t, collector)["sovdev.operations.total"]
+			if m == nil || m.GetSum() == nil {
+				t.Fatalf("sovdev.operations.total not exported as a sum: %v", m)
+			}
+			if got := m.GetSum().AggregationTemporality; got != tt.want {
+				t.Errorf("temporality = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOperationMetricAttributes(t *testing.T) {
+	l, _, collector := newCollectorLogger(t, Config{PeerServices: map[string]string{"BRREG": "SYS1234567"}})
+
+	if err := l.Log(SOVDEV_LOGLEVELS.INFO, "TestMetricAttributes", "Counted", "BRREG", nil, nil, nil, ""); err != nil {
+		t.Fatalf("Log: %v", err)
+	}
+	if err := l.Log(SOVDEV_LOGLEVELS.ERROR, "TestMetricAttributes", "Failed", "BRREG", nil, nil, errors.New("boom"), ""); err != nil {
+		t.Fatalf("Log: %v", err)
+	}
+	if err := l.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	metrics := exportedMetrics(t, collector)
+	for _, name := range []string{"sovdev.operations.total", "sovdev.errors.total", "sovdev.operation.duration"} {
+		m := metrics[name]
+		if m == nil {
+			t.Errorf("%s not exported", name)
+			continue
+		}
+		var points [][]*commonpb.KeyValue
+		for _, dp := range m.GetSum().GetDataPoints() {
+			points = append(points, dp.Attributes)
+		}
+		for _, dp := range m.GetHistogram().GetDataPoints() {
+			points = append(points, dp.Attributes)
+		}
+		if len(points) == 0 {
+			t.Errorf("%s has no data points", name)
+		}
+		for _, attrs := range points {
+			got := make(map[string]string)
+			for _, kv := range attrs {
+				got[kv.Key] = kv.Value.GetStringValue()
+			}
+			if len(got) != 3 || got["peer_service"] != "SYS1234567" || got["log_type"] != "transaction" || got["log_level"] == "" {
+				t.Errorf("%s attributes = %v, want only peer_service, log_type and log_level", name, got)
+			}
+		}
+	}
+}
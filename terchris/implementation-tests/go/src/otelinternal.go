@@ -0,0 +1,198 @@
+package sovdevlogger
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel"
+)
+
+// logTypeOTELInternal marks entries produced by the OpenTelemetry SDK itself
+const logTypeOTELInternal SovdevLogType = "otel.internal"
+
+// otelInternalQueueSize bounds pending SDK diagnostics; extra messages are dropped
+const otelInternalQueueSize = 256
+
+// spuriousOTELMessages are SDK diagnostics that do not indicate a problem.
+// sdk/log v0.14 warns about dropped attributes on the first record even when
+// nothing was dropped (Record.SetAttributes always calls setDropped(0)).
+var spuriousOTELMessages = map[string]bool{
+	"limit reached: dropping log Record attributes": true,
+}
+
+// otelInternalEntry is one SDK diagnostic waiting to be logged
+type otelInternalEntry struct {
+	level        SovdevLogLevel
+	functionName string
+	message      string
+	input        map[string]interface{}
+	err          error
+}
+
+// otelInternalQueue hands SDK diagnostics to the worker that logs them. Closed
+// by Logger.Shutdown: the worker stops, and diagnostics arriving later (the SDK
+// handlers stay installed until the next initialization) are dropped.
+type otelInternalQueue struct {
+	mu      sync.RWMutex // guards closed against sends on the closed channel
+	closed  bool
+	entries chan otelInternalEntry
+	done    chan struct{}
+}
+
+// enqueue queues e without blocking the SDK; drops it when the queue is full or closed
+func (q *otelInternalQueue) enqueue(e otelInternalEntry) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return
+	}
+	select {
+	case q.entries <- e:
+	default:
+	}
+}
+
+// close stops the worker once it has logged the queued diagnostics, or when ctx
+// is done. Repeated calls are safe.
+func (q *otelInternalQueue) close(ctx context.Context) {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.entries)
+	}
+	q.mu.Unlock()
+
+	select {
+	case <-q.done:
+	case <-ctx.Done():
+	}
+}
+
+// otelLogVerbosity maps OTEL_LOG_LEVEL to the logr verbosity used by the OTEL SDK
+// (errors always pass, warnings are V(1), info V(4), debug V(8)). -1 disables routing.
+func otelLogVerbosity(level string) int {
+	switch strings.ToLower(level) {
+	case "none", "off":
+		return -1
+	case "error":
+		return 0
+	case "", "warn", "warning":
+		return 1
+	case "info":
+		return 4
+	case "debug", "verbose", "all":
+		return 8
+	default:
+		fmt.Printf("⚠️  Unknown OTEL_LOG_LEVEL %q, using warn\n", level)
+		return 1
+	}
+}
+
+// installOTELInternalLogging routes the OTEL SDK's error handler and internal
+// logger through l, so a failing exporter shows up in the normal log stream as
+// log_type "otel.internal" instead of going nowhere. Global, so only done for
+// the default logger; the worker stops when l is shut down.
+func installOTELInternalLogging(l *Logger, level string) {
+	if level == "" {
+		level = os.Getenv("OTEL_LOG_LEVEL")
+	}
+	verbosity := otelLogVerbosity(level)
+	if verbosity < 0 {
+		return
+	}
+
+	// The SDK may call its logger while holding internal locks (e.g. from Emit), so
+	// entries are handed to a worker instead of re-entering the SDK synchronously
+	queue := &otelInternalQueue{entries: make(chan otelInternalEntry, otelInternalQueueSize), done: make(chan struct{})}
+	go func() {
+		defer close(queue.done)
+		for e := range queue.entries {
+			l.log(context.Background(), e.level, e.functionName, e.message, l.selfPeerName, e.input, nil, e.err, "", logTypeOTELInternal)
+		}
+	}()
+	l.otelInternal = queue
+
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		// Export failures also arrive here; throttle so a dead collector cannot flood the log
+		if ok, _ := l.selfLogThrottle.allow("sdk.error"); !ok {
+			return
+		}
+		queue.enqueue(otelInternalEntry{level: SOVDEV_LOGLEVELS.WARN, functionName: "otel", message: "OpenTelemetry SDK error", err: err})
+	}))
+	otel.SetLogger(logr.New(&otelLogSink{queue: queue, verbosity: verbosity}))
+}
+
+// otelLogSink is a logr.LogSink writing OTEL SDK diagnostics as sovdev entries
+type otelLogSink struct {
+	queue     *otelInternalQueue
+	verbosity int
+	name      string
+	values    []interface{}
+}
+
+func (s *otelLogSink) Init(logr.RuntimeInfo) {}
+
+func (s *otelLogSink) Enabled(level int) bool {
+	return level <= s.verbosity
+}
+
+func (s *otelLogSink) Info(level int, msg string, keysAndValues ...interface{}) {
+	if spuriousOTELMessages[msg] {
+		return
+	}
+	sovdevLevel := SOVDEV_LOGLEVELS.DEBUG
+	switch {
+	case level <= 1:
+		sovdevLevel = SOVDEV_LOGLEVELS.WARN
+	case level <= 4:
+		sovdevLevel = SOVDEV_LOGLEVELS.INFO
+	}
+	s.queue.enqueue(otelInternalEntry{level: sovdevLevel, functionName: s.functionName(), message: msg, input: s.input(keysAndValues)})
+}
+
+func (s *otelLogSink) Error(err error, msg string, keysAndValues ...interface{}) {
+	s.queue.enqueue(otelInternalEntry{level: SOVDEV_LOGLEVELS.WARN, functionName: s.functionName(), message: msg, input: s.input(keysAndValues), err: err})
+}
+
+func (s *otelLogSink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	clone := *s
+	clone.values = append(append([]interface{}{}, s.values...), keysAndValues...)
+	return &clone
+}
+
+func (s *otelLogSink) WithName(name string) logr.LogSink {
+	clone := *s
+	if clone.name != "" {
+		name = clone.name + "." + name
+	}
+	clone.name = name
+	return &clone
+}
+
+func (s *otelLogSink) functionName() string {
+	if s.name == "" {
+		return "otel"
+	}
+	return "otel." + s.name
+}
+
+// input turns logr key/value pairs into an input_json map
+func (s *otelLogSink) input(keysAndValues []interface{}) map[string]interface{} {
+	all := append(append([]interface{}{}, s.values...), keysAndValues...)
+	if len(all) == 0 {
+		return nil
+	}
+
+	input := make(map[string]interface{}, len(all)/2)
+	for i := 0; i+1 < len(all); i += 2 {
+		input[fmt.Sprint(all[i])] = fmt.Sprint(all[i+1])
+	}
+	return input
+}
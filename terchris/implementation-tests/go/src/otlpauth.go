@@ -0,0 +1,114 @@
+package sovdevlogger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenFile holds a bearer token read from a file, re-reading it when the file
+// changes so rotated secrets (e.g. projected Kubernetes tokens) are picked up
+type tokenFile struct {
+	path    string
+	mu      sync.Mutex
+	token   string
+	modTime time.Time
+}
+
+// newTokenFile reads the token once so configuration errors surface at init
+func newTokenFile(path string) (*tokenFile, error) {
+	tf := &tokenFile{path: path}
+	if _, err := tf.Token(); err != nil {
+		return nil, err
+	}
+	return tf, nil
+}
+
+// Token returns the current token, re-reading the file if its modification time changed.
+// If a refresh fails or finds the file empty (e.g. caught mid-rewrite by a secret
+// rotation) the last known token is kept, and the file is read again next time.
+func (tf *tokenFile) Token() (string, error) {
+	tf.mu.Lock()
+	defer tf.mu.Unlock()
+
+	info, err := os.Stat(tf.path)
+	if err != nil {
+		if tf.token != "" {
+			return tf.token, nil
+		}
+		return "", fmt.Errorf("read OTLP token file: %w", err)
+	}
+	if tf.token != "" && info.ModTime().Equal(tf.modTime) {
+		return tf.token, nil
+	}
+
+	data, err := os.ReadFile(tf.path)
+	if err != nil {
+		if tf.token != "" {
+			return tf.token, nil
+		}
+		return "", fmt.Errorf("read OTLP token file: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		if tf.token != "" {
+			return tf.token, nil
+		}
+		return "", fmt.Errorf("OTLP token file %s is empty", tf.path)
+	}
+
+	tf.token = token
+	tf.modTime = info.ModTime()
+	return tf.token, nil
+}
+
+// bearerTokenTransport is an HTTP RoundTripper that adds an Authorization header
+type bearerTokenTransport struct {
+	base  http.RoundTripper
+	token *tokenFile
+}
+
+func (t *bearerTokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.token.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	// RoundTrippers must not modify the caller's request
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return t.base.RoundTrip(req)
+}
+
+// sensitiveHeaderPattern matches header names whose values are credentials
+var sensitiveHeaderPattern = regexp.MustCompile(`(?i)authorization|api[-_]?key|token|cookie|secret|password`)
+
+// describeHeaders renders headers for diagnostics with credential values masked.
+// Sensitive values are replaced by a short SHA-256 fingerprint so operators can
+// still tell whether two deployments use the same secret.
+// Example: {"Host": "otel.local", "Authorization": "Bearer x"} -> "Authorization=[REDACTED sha256:<8 hex>], Host=otel.local"
+func describeHeaders(headers map[string]string) string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		value := headers[name]
+		if sensitiveHeaderPattern.MatchString(name) {
+			sum := sha256.Sum256([]byte(value))
+			value = "[REDACTED sha256:" + hex.EncodeToString(sum[:4]) + "]"
+		}
+		parts = append(parts, name+"="+value)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,62 @@
+package sovdevlogger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTokenFileRefresh(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	write := func(content string, modTime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	start := time.Now().Add(-time.Hour)
+
+	write("first-token\n", start)
+	tf, err := newTokenFile(path)
+	if err != nil {
+		t.Fatalf("newTokenFile: %v", err)
+	}
+
+	steps := []struct {
+		name    string
+		content string // "-" removes the file
+		want    string
+	}{
+		{"emptied mid-rewrite", "", "first-token"},
+		{"rewritten", "second-token", "second-token"},
+		{"removed", "-", "second-token"},
+		{"recreated", "third-token", "third-token"},
+	}
+	for i, step := range steps {
+		if step.content == "-" {
+			if err := os.Remove(path); err != nil {
+				t.Fatal(err)
+			}
+		} else {
+			write(step.content, start.Add(time.Duration(i+1)*time.Minute))
+		}
+		got, err := tf.Token()
+		if err != nil || got != step.want {
+			t.Errorf("%s: Token() = %q, %v, want %q", step.name, got, err, step.want)
+		}
+	}
+}
+
+func TestTokenFileEmptyAtInit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte(" \n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newTokenFile(path); err == nil {
+		t.Error("newTokenFile accepted an empty token file")
+	}
+}
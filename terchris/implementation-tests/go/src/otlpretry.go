@@ -0,0 +1,63 @@
+package sovdevlogger
+
+import (
+	"fmt"
+	"time"
+)
+
+// Defaults of the OTLP exporters' own retry policy
+const (
+	defaultOTLPRetryInitialInterval = 5 * time.Second
+	defaultOTLPRetryMaxInterval     = 30 * time.Second
+	defaultOTLPRetryMaxElapsedTime  = time.Minute
+)
+
+// otlpRetryConfig is the retry policy of all OTLP exporters. Its fields match
+// the exporters' RetryConfig types, so it converts to each of them directly.
+type otlpRetryConfig struct {
+	Enabled         bool
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	MaxElapsedTime  time.Duration
+}
+
+// otlpRetry returns the retry policy from Config, the SOVDEV_OTLP_RETRY_* env
+// vars or the exporters' defaults
+func otlpRetry(cfg Config) otlpRetryConfig {
+	r := otlpRetryConfig{
+		Enabled:         !cfg.OTLPRetryDisabled && getEnvBool("SOVDEV_OTLP_RETRY_ENABLED", true),
+		InitialInterval: cfg.OTLPRetryInitialInterval,
+		MaxInterval:     cfg.OTLPRetryMaxInterval,
+		MaxElapsedTime:  cfg.OTLPRetryMaxElapsedTime,
+	}
+	if r.InitialInterval <= 0 {
+		r.InitialInterval = getEnvDuration("SOVDEV_OTLP_RETRY_INITIAL_INTERVAL", defaultOTLPRetryInitialInterval)
+	}
+	if r.MaxInterval <= 0 {
+		r.MaxInterval = getEnvDuration("SOVDEV_OTLP_RETRY_MAX_INTERVAL", defaultOTLPRetryMaxInterval)
+	}
+	if r.MaxElapsedTime <= 0 {
+		r.MaxElapsedTime = getEnvDuration("SOVDEV_OTLP_RETRY_MAX_ELAPSED_TIME", defaultOTLPRetryMaxElapsedTime)
+	}
+	if r.MaxInterval < r.InitialInterval {
+		r.MaxInterval = r.InitialInterval
+	}
+	return r
+}
+
+// isDefault reports whether r is the policy the exporters use without WithRetry
+func (r otlpRetryConfig) isDefault() bool {
+	return r == otlpRetryConfig{
+		Enabled:         true,
+		InitialInterval: defaultOTLPRetryInitialInterval,
+		MaxInterval:     defaultOTLPRetryMaxInterval,
+		MaxElapsedTime:  defaultOTLPRetryMaxElapsedTime,
+	}
+}
+
+func (r otlpRetryConfig) String() string {
+	if !r.Enabled {
+		return "disabled"
+	}
+	return fmt.Sprintf("initial %s, max %s, give up after %s", r.InitialInterval, r.MaxInterval, r.MaxElapsedTime)
+}
@@ -0,0 +1,137 @@
+package sovdevlogger
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOTLPRetryConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		env  map[string]string
+		want otlpRetryConfig
+	}{
+		{
+			"defaults",
+			Config{},
+			nil,
+			otlpRetryConfig{true, 5 * time.Second, 30 * time.Second, time.Minute},
+		},
+		{
+			"config",
+			Config{OTLPRetryInitialInterval: time.Second, OTLPRetryMaxInterval: 2 * time.Second, OTLPRetryMaxElapsedTime: 10 * time.Second},
+			nil,
+			otlpRetryConfig{true, time.Second, 2 * time.Second, 10 * time.Second},
+		},
+		{
+			"env",
+			Config{},
+			map[string]string{"SOVDEV_OTLP_RETRY_INITIAL_INTERVAL": "500ms", "SOVDEV_OTLP_RETRY_MAX_INTERVAL": "2s", "SOVDEV_OTLP_RETRY_MAX_ELAPSED_TIME": "5m"},
+			otlpRetryConfig{true, 500 * time.Millisecond, 2 * time.Second, 5 * time.Minute},
+		},
+		{
+			"config wins over env",
+			Config{OTLPRetryMaxElapsedTime: 10 * time.Second},
+			map[string]string{"SOVDEV_OTLP_RETRY_MAX_ELAPSED_TIME": "5m"},
+			otlpRetryConfig{true, 5 * time.Second, 30 * time.Second, 10 * time.Second},
+		},
+		{
+			"max interval raised to the initial interval",
+			Config{OTLPRetryInitialInterval: time.Minute},
+			nil,
+			otlpRetryConfig{true, time.Minute, time.Minute, time.Minute},
+		},
+		{
+			"disabled in config",
+			Config{OTLPRetryDisabled: true},
+			nil,
+			otlpRetryConfig{false, 5 * time.Second, 30 * time.Second, time.Minute},
+		},
+		{
+			"disabled by env",
+			Config{},
+			map[string]string{"SOVDEV_OTLP_RETRY_ENABLED": "false"},
+			otlpRetryConfig{false, 5 * time.Second, 30 * time.Second, time.Minute},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"SOVDEV_OTLP_RETRY_ENABLED", "SOVDEV_OTLP_RETRY_INITIAL_INTERVAL", "SOVDEV_OTLP_RETRY_MAX_INTERVAL", "SOVDEV_OTLP_RETRY_MAX_ELAPSED_TIME"} {
+				t.Setenv(key, tt.env[key])
+			}
+			got := otlpRetry(tt.cfg)
+			if got != tt.want {
+				t.Errorf("otlpRetry() = %+v, want %+v", got, tt.want)
+			}
+			if got.isDefault() != (tt.name == "defaults") {
+				t.Errorf("isDefault() = %v", got.isDefault())
+			}
+		})
+	}
+}
+
+func TestOTLPRetryIsApplied(t *testing.T) {
+	tests := []struct {
+		name      string
+		disabled  bool
+		wantRetry bool
+	}{
+		{"enabled", false, true},
+		{"disabled", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int64
+			unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/v1/logs" {
+					attempts.Add(1)
+				}
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer unavailable.Close()
+
+			testEnv(t)
+			t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", unavailable.URL)
+			l, err := NewLogger(Config{
+				ServiceName:              "sovdev-test",
+				ServiceVersion:           "1.0.0",
+				OTLPRetryDisabled:        tt.disabled,
+				OTLPRetryInitialInterval: 10 * time.Millisecond,
+				OTLPRetryMaxInterval:     20 * time.Millisecond,
+				OTLPRetryMaxElapsedTime:  300 * time.Millisecond,
+				OTLPTimeout:              100 * time.Millisecond,
+			})
+			if err != nil {
+				t.Fatalf("NewLogger: %v", err)
+			}
+			defer func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+				defer cancel()
+				_ = l.Shutdown(ctx)
+			}()
+
+			if err := l.Log(SOVDEV_LOGLEVELS.INFO, "TestRetry", "Retried", "", nil, nil, nil, ""); err != nil {
+				t.Fatalf("Log: %v", err)
+			}
+			start := time.Now()
+			_ = l.Flush()
+			// Bounded by OTLPRetryMaxElapsedTime, not the one-minute default
+			if elapsed := time.Since(start); elapsed > 3*time.Second {
+				t.Errorf("flush against an unavailable collector took %s", elapsed)
+			}
+
+			n := attempts.Load()
+			if tt.wantRetry && n < 2 {
+				t.Errorf("%d export attempts with retry enabled, want several", n)
+			}
+			if !tt.wantRetry && n != 1 {
+				t.Errorf("%d export attempts with retry disabled, want 1", n)
+			}
+		})
+	}
+}
@@ -1,19 +1,38 @@
 package sovdevlogger
 
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// peerServiceInternal is the default reserved friendly name for the service itself
+// (see Config.SelfPeerName)
+const peerServiceInternal = "INTERNAL"
+
 // PeerServices holds the peer service mappings with type-safe constants
 type PeerServices struct {
-	// INTERNAL is auto-generated and equals the service name
+	// INTERNAL is the reserved friendly name for the service itself: "INTERNAL",
+	// or the name given to CreatePeerServicesWithSelfName. The logger resolves it
+	// to the service name when it matches Config.SelfPeerName (see Resolve).
 	INTERNAL string
-	// Mappings contains the original peer service definitions plus INTERNAL
+	// Mappings contains the peer service definitions as given (friendly name to
+	// system ID). It has no INTERNAL entry, since the service name is only known
+	// at initialization; use Resolve to get the logged peer_service value.
 	Mappings map[string]string
 	// constants holds the defined peer service constant names
 	constants map[string]string
 }
 
 // Get returns the constant name for a peer service
-// This provides type-safe access to peer service identifiers
+// This provides type-safe access to peer service identifiers.
+// Safe on a nil PeerServices, which returns name as-is.
 func (ps *PeerServices) Get(name string) string {
-	if name == "INTERNAL" {
+	if ps == nil {
+		return name
+	}
+	if name == ps.INTERNAL {
 		return ps.INTERNAL
 	}
 	if _, ok := ps.constants[name]; ok {
@@ -22,7 +41,19 @@ func (ps *PeerServices) Get(name string) string {
 	return name // Return as-is if not found
 }
 
-// CreatePeerServices creates a PeerServices instance with INTERNAL auto-generated
+// Lookup returns the system ID defined for a friendly name, like
+// ps.Mappings[name], and whether it is defined. Unlike indexing Mappings it is
+// safe on a nil PeerServices, where nothing is defined.
+func (ps *PeerServices) Lookup(name string) (string, bool) {
+	if ps == nil {
+		return "", false
+	}
+	systemID, ok := ps.Mappings[name]
+	return systemID, ok
+}
+
+// CreatePeerServices creates a PeerServices instance with INTERNAL auto-generated.
+// A nil definitions map gives an instance with only INTERNAL and empty Mappings.
 //
 // Example:
 //
@@ -30,10 +61,28 @@ func (ps *PeerServices) Get(name string) string {
 //	    "BRREG": "SYS1234567",  // External system
 //	    "ALTINN": "SYS7654321", // External system
 //	})
-//	// INTERNAL is auto-generated = "INTERNAL"
-//	// peerServices.Mappings contains all mappings including INTERNAL
+//	// peerServices.INTERNAL = "INTERNAL", logged as the service name
+//	// peerServices.Mappings contains BRREG and ALTINN only
 func CreatePeerServices(definitions map[string]string) *PeerServices {
-	// Create mappings with INTERNAL pre-populated
+	return CreatePeerServicesWithSelfName(peerServiceInternal, definitions)
+}
+
+// CreatePeerServicesWithSelfName is CreatePeerServices with another reserved name
+// for the service itself, e.g. for a service that talks to a peer called INTERNAL
+// or prefers a localized term. Use the same name as Config.SelfPeerName:
+//
+//	peerServices := CreatePeerServicesWithSelfName("SELV", map[string]string{
+//	    "INTERNAL": "SYS1111111", // an ordinary peer now
+//	})
+//	SovdevInitializeWithConfig(Config{ServiceName: "x", SelfPeerName: peerServices.INTERNAL, PeerServices: peerServices.Mappings})
+//
+// An empty selfName means "INTERNAL".
+func CreatePeerServicesWithSelfName(selfName string, definitions map[string]string) *PeerServices {
+	if selfName == "" {
+		selfName = peerServiceInternal
+	}
+
+	// Copy the definitions; the self name is resolved by the logger, not mapped here
 	mappings := make(map[string]string)
 
 	// Copy all definitions
@@ -48,8 +97,243 @@ func CreatePeerServices(definitions map[string]string) *PeerServices {
 	}
 
 	return &PeerServices{
-		INTERNAL:  "INTERNAL", // Always "INTERNAL" string
+		INTERNAL:  selfName,
 		Mappings:  mappings,
 		constants: constants,
 	}
 }
+
+// Resolve returns the peer_service value the default logger writes for name:
+// the service name for the logger's self name (INTERNAL unless Config.SelfPeerName
+// is set; ps.INTERNAL before SovdevInitialize, resolving to ""), the system ID
+// for a defined friendly name, and name unchanged otherwise. A nil PeerServices
+// defines no friendly names.
+func (ps *PeerServices) Resolve(name string) string {
+	var mappings map[string]string
+	selfName := peerServiceInternal
+	if ps != nil {
+		mappings = ps.Mappings
+		if ps.INTERNAL != "" {
+			selfName = ps.INTERNAL
+		}
+	}
+
+	serviceName := ""
+	if logger := defaultLogger(); logger != nil {
+		serviceName = logger.serviceName
+		selfName = logger.selfPeerName
+	}
+
+	return resolvePeerName(name, selfName, serviceName, mappings)
+}
+
+// resolvePeerName maps a friendly name to its system ID. Empty and selfName
+// resolve to serviceName, even if mappings has a selfName entry. Names not in
+// mappings (including literal system IDs) are returned unchanged, so a friendly
+// name that equals another system ID wins; PeerServices.Validate reports that.
+func resolvePeerName(name, selfName, serviceName string, mappings map[string]string) string {
+	if name == "" || name == selfName {
+		return serviceName
+	}
+	if systemID, ok := mappings[name]; ok {
+		return systemID
+	}
+	return name
+}
+
+// metricOtherValue replaces unknown values in metric attributes when
+// Config.BoundMetricLabels is set
+const metricOtherValue = "other"
+
+// peerSystemIDs returns the set of system IDs in mappings
+func peerSystemIDs(mappings map[string]string) map[string]bool {
+	ids := make(map[string]bool, len(mappings))
+	for _, id := range mappings {
+		ids[id] = true
+	}
+	return ids
+}
+
+// metricPeerService returns the peer_service metric attribute for a resolved
+// peer service: unchanged, or "other" with BoundMetricLabels unless it is the
+// service itself or a mapped system ID
+func (l *Logger) metricPeerService(resolved string) string {
+	if !l.boundMetricLabels || resolved == l.serviceName {
+		return resolved
+	}
+	l.peerMu.RLock()
+	known := l.peerSystemIDs[resolved]
+	l.peerMu.RUnlock()
+
+	if known {
+		return resolved
+	}
+	return metricOtherValue
+}
+
+// SovdevAddPeerService adds or replaces a peer service mapping on the default
+// logger (see Logger.AddPeerService)
+func SovdevAddPeerService(friendlyName, systemID string) error {
+	logger := defaultLogger()
+	if logger == nil {
+		return fmt.Errorf("sovdev-logger not initialized")
+	}
+	return logger.AddPeerService(friendlyName, systemID)
+}
+
+// AddPeerService adds or replaces a peer service mapping after initialization,
+// e.g. for peers discovered from configuration at runtime. Safe to call while
+// other goroutines log. The self name (INTERNAL) is reserved and an empty system ID is rejected.
+// Mappings added this way are not carried over by SovdevReconfigure.
+func (l *Logger) AddPeerService(friendlyName, systemID string) error {
+	if friendlyName == "" || friendlyName == l.selfPeerName {
+		return fmt.Errorf("invalid peer service name %q", friendlyName)
+	}
+	if systemID == "" {
+		return fmt.Errorf("peer service %s has an empty system ID", friendlyName)
+	}
+
+	l.peerMu.Lock()
+	defer l.peerMu.Unlock()
+
+	if previous, ok := l.peerServiceMap[friendlyName]; ok && previous != systemID {
+		// Keep the old ID if another name still maps to it
+		stillMapped := false
+		for name, id := range l.peerServiceMap {
+			if name != friendlyName && id == previous {
+				stillMapped = true
+				break
+			}
+		}
+		if !stillMapped {
+			delete(l.peerSystemIDs, previous)
+		}
+	}
+	l.peerServiceMap[friendlyName] = systemID
+	l.peerSystemIDs[systemID] = true
+	return nil
+}
+
+// SovdevLogWithSystemID logs a transaction against a peer known only by its
+// system ID, e.g. from generic HTTP client instrumentation that maps the remote
+// host to an ID but does not know this service's friendly names. systemID is
+// written verbatim as peer_service, without friendly-name resolution; when a
+// mapping has that ID, its friendly name is added as attribute peer_service_name.
+// Code that knows the peer should keep using SovdevLog/SovdevLogContext with the
+// friendly name, so a changed system ID is fixed in one place.
+func SovdevLogWithSystemID(ctx context.Context, level SovdevLogLevel, functionName, message, systemID string, inputJSON, responseJSON interface{}, exception error, traceID string) {
+	logger := defaultLogger()
+	if logger == nil {
+		bufferPreInit(func(replayCtx context.Context, l *Logger) error {
+			return l.LogWithSystemID(withEntryTime(ctx, entryTime(replayCtx)), level, functionName, message, systemID, inputJSON, responseJSON, exception, traceID)
+		})
+		return
+	}
+
+	if err := logger.LogWithSystemID(ctx, level, functionName, message, systemID, inputJSON, responseJSON, exception, traceID); err != nil {
+		fmt.Printf("⚠️  Log entry rejected: %v\n", err)
+	}
+}
+
+// LogWithSystemID logs with systemID as peer_service (see SovdevLogWithSystemID).
+// An empty systemID falls back to the usual resolution.
+func (l *Logger) LogWithSystemID(ctx context.Context, level SovdevLogLevel, functionName, message, systemID string, inputJSON, responseJSON interface{}, exception error, traceID string) error {
+	if systemID == "" {
+		return l.LogContext(ctx, level, functionName, message, "", inputJSON, responseJSON, exception, traceID)
+	}
+
+	var attributes map[string]interface{}
+	if name := l.friendlyPeerName(systemID); name != "" {
+		attributes = map[string]interface{}{"peer_service_name": name}
+	}
+	ctx = context.WithValue(ctx, systemIDKey, systemID)
+	return l.logAttrs(ctx, level, functionName, message, "", inputJSON, responseJSON, exception, traceID, SOVDEV_LOGTYPES.TRANSACTION, attributes)
+}
+
+// peerServiceFor returns the peer_service of a log call: the system ID passed
+// to LogWithSystemID, otherwise peerService (or the ctx default) resolved
+func (l *Logger) peerServiceFor(ctx context.Context, peerService string) string {
+	if ctx != nil {
+		if systemID, ok := ctx.Value(systemIDKey).(string); ok {
+			return systemID
+		}
+	}
+	return l.resolvePeerService(peerServiceOrDefault(ctx, peerService))
+}
+
+// friendlyPeerName returns the friendly name mapped to systemID, or "". If
+// several names map to it (see PeerServices.Validate) the first in sort order wins.
+func (l *Logger) friendlyPeerName(systemID string) string {
+	l.peerMu.RLock()
+	defer l.peerMu.RUnlock()
+
+	found := ""
+	for name, id := range l.peerServiceMap {
+		if id == systemID && (found == "" || name < found) {
+			found = name
+		}
+	}
+	return found
+}
+
+// Validate reports mappings that make peer_service ambiguous:
+//   - two friendly names mapping to the same system ID, so the ID no longer tells
+//     which name the code used
+//   - a friendly name that equals another entry's system ID, so passing that ID
+//     literally resolves to a different system
+//   - an entry for the self name (INTERNAL by default; reserved, always the service itself)
+//   - an empty system ID
+//
+// Identity mappings ("SYS1234567": "SYS1234567") are allowed. Resolution itself
+// never fails: a known friendly name is replaced by its ID and anything else is
+// used as-is, so call Validate at startup to catch these mistakes early.
+func (ps *PeerServices) Validate() error {
+	if ps == nil {
+		return nil
+	}
+	selfName := ps.INTERNAL
+	if selfName == "" {
+		selfName = peerServiceInternal
+	}
+	names := make([]string, 0, len(ps.Mappings))
+	for name := range ps.Mappings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []string
+	namesByID := make(map[string][]string)
+	for _, name := range names {
+		id := ps.Mappings[name]
+		switch {
+		case name == selfName:
+			problems = append(problems, selfName+" is reserved for the service itself")
+		case id == "":
+			problems = append(problems, fmt.Sprintf("%s has an empty system ID", name))
+		default:
+			namesByID[id] = append(namesByID[id], name)
+		}
+	}
+
+	for _, name := range names {
+		if others, ok := namesByID[name]; ok && ps.Mappings[name] != name {
+			problems = append(problems, fmt.Sprintf("%s is both a friendly name and the system ID of %s", name, strings.Join(others, ", ")))
+		}
+	}
+
+	ids := make([]string, 0, len(namesByID))
+	for id := range namesByID {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if len(namesByID[id]) > 1 {
+			problems = append(problems, fmt.Sprintf("%s is mapped from %s", id, strings.Join(namesByID[id], ", ")))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("ambiguous peer services: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
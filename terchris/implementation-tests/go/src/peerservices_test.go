@@ -0,0 +1,243 @@
+package sovdevlogger
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestPeerServicesValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		mappings map[string]string
+		wantErr  string
+	}{
+		{"unambiguous", map[string]string{"BRREG": "SYS1234567", "ALTINN": "SYS7654321"}, ""},
+		{"identity mapping", map[string]string{"SYS1234567": "SYS1234567"}, ""},
+		{"duplicate system ID", map[string]string{"BRREG": "SYS1234567", "ENHETSREGISTERET": "SYS1234567"},
+			"SYS1234567 is mapped from BRREG, ENHETSREGISTERET"},
+		{"name equals another system ID", map[string]string{"BRREG": "ALTINN", "ALTINN": "SYS7654321"},
+			"ALTINN is both a friendly name and the system ID of BRREG"},
+		{"reserved self name", map[string]string{"INTERNAL": "SYS1234567"}, "INTERNAL is reserved"},
+		{"empty system ID", map[string]string{"BRREG": ""}, "BRREG has an empty system ID"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps := &PeerServices{INTERNAL: peerServiceInternal, Mappings: tt.mappings}
+			err := ps.Validate()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("Validate() = %v, want nil", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("Validate() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	var nilServices *PeerServices
+	if err := nilServices.Validate(); err != nil {
+		t.Errorf("nil PeerServices: Validate() = %v, want nil", err)
+	}
+}
+
+func TestResolvePeerNameCollisions(t *testing.T) {
+	mappings := map[string]string{"BRREG": "ALTINN", "ALTINN": "SYS7654321", "SYS1234567": "SYS1234567"}
+
+	tests := map[string]string{
+		"":           "my-service",
+		"INTERNAL":   "my-service",
+		"BRREG":      "ALTINN",
+		"ALTINN":     "SYS7654321", // the friendly name wins over the literal ID
+		"SYS1234567": "SYS1234567",
+		"SYS0000000": "SYS0000000", // unknown names are used as-is
+	}
+	for name, want := range tests {
+		if got := resolvePeerName(name, peerServiceInternal, "my-service", mappings); got != want {
+			t.Errorf("resolvePeerName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestInternalResolvesToServiceName(t *testing.T) {
+	ps := CreatePeerServices(map[string]string{"BRREG": "SYS1234567"})
+	if ps.INTERNAL != "INTERNAL" {
+		t.Errorf("INTERNAL = %q, want INTERNAL", ps.INTERNAL)
+	}
+	if _, ok := ps.Mappings["INTERNAL"]; ok {
+		t.Error("Mappings has an INTERNAL entry; the service itself is resolved by the logger")
+	}
+
+	l, sink := newTestLogger(t, Config{ServiceName: "company-lookup", PeerServices: ps.Mappings})
+	for _, peer := range []string{ps.INTERNAL, ""} {
+		if err := l.Log(SOVDEV_LOGLEVELS.INFO, "TestInternal", "Self call", peer, nil, nil, nil, ""); err != nil {
+			t.Fatalf("Log: %v", err)
+		}
+		if got := sink.Entries()[len(sink.Entries())-1].PeerService; got != "company-lookup" {
+			t.Errorf("peer_service for %q = %q, want the service name", peer, got)
+		}
+	}
+	if got := l.resolvePeerService(ps.Get("BRREG")); got != "SYS1234567" {
+		t.Errorf("BRREG resolves to %q, want SYS1234567", got)
+	}
+}
+
+func TestResolveUsesDefaultLoggerServiceName(t *testing.T) {
+	ps := CreatePeerServices(map[string]string{"BRREG": "SYS1234567"})
+	testEnv(t)
+	t.Cleanup(SovdevReset)
+	if got := ps.Resolve(ps.INTERNAL); got != "" {
+		t.Errorf("Resolve(INTERNAL) before initialization = %q, want empty", got)
+	}
+
+	cfg := testConfig("1.0.0")
+	cfg.ServiceName = "company-lookup"
+	cfg.PeerServices = ps.Mappings
+	if err := SovdevInitializeWithConfig(cfg); err != nil {
+		t.Fatalf("SovdevInitializeWithConfig: %v", err)
+	}
+	if got := ps.Resolve(ps.INTERNAL); got != "company-lookup" {
+		t.Errorf("Resolve(INTERNAL) = %q, want the service name", got)
+	}
+	if got := ps.Resolve("BRREG"); got != "SYS1234567" {
+		t.Errorf("Resolve(BRREG) = %q, want SYS1234567", got)
+	}
+}
+
+func TestCustomSelfName(t *testing.T) {
+	ps := CreatePeerServicesWithSelfName("SELV", map[string]string{"INTERNAL": "SYS1111111"})
+	l, sink := newTestLogger(t, Config{ServiceName: "company-lookup", SelfPeerName: ps.INTERNAL, PeerServices: ps.Mappings})
+
+	for peer, want := range map[string]string{"SELV": "company-lookup", "INTERNAL": "SYS1111111"} {
+		if err := l.Log(SOVDEV_LOGLEVELS.INFO, "TestSelfName", "Call", peer, nil, nil, nil, ""); err != nil {
+			t.Fatalf("Log: %v", err)
+		}
+		if got := sink.Entries()[len(sink.Entries())-1].PeerService; got != want {
+			t.Errorf("peer_service for %q = %q, want %q", peer, got, want)
+		}
+	}
+}
+
+// Run with -race: AddPeerService must not race with logging
+func TestCustomSelfNameWithDefaultLogger(t *testing.T) {
+	ps := CreatePeerServicesWithSelfName("SELV", map[string]string{"INTERNAL": "SYS1111111"})
+	if ps.INTERNAL != "SELV" {
+		t.Fatalf("INTERNAL = %q, want SELV", ps.INTERNAL)
+	}
+	if err := ps.Validate(); err != nil {
+		t.Errorf("INTERNAL is an ordinary peer with a custom self name, Validate() = %v", err)
+	}
+	reserved := CreatePeerServicesWithSelfName("SELV", map[string]string{"SELV": "SYS1111111"})
+	if err := reserved.Validate(); err == nil || !strings.Contains(err.Error(), "SELV is reserved") {
+		t.Errorf("Validate() with a SELV mapping = %v, want SELV reserved", err)
+	}
+
+	sink := &recordingSink{}
+	cfg := testConfig("1.0.0")
+	cfg.ServiceName = "company-lookup"
+	cfg.SelfPeerName = ps.INTERNAL
+	cfg.PeerServices = ps.Mappings
+	cfg.Sinks = []Sink{sink}
+	initTestDefault(t, cfg)
+
+	for peer, want := range map[string]string{"SELV": "company-lookup", "": "company-lookup", "INTERNAL": "SYS1111111"} {
+		if got := ps.Resolve(peer); got != want {
+			t.Errorf("Resolve(%q) = %q, want %q", peer, got, want)
+		}
+		SovdevLog(SOVDEV_LOGLEVELS.INFO, "TestSelfName", "Call", peer, nil, nil, nil, "")
+		if got := sink.Entries()[len(sink.Entries())-1].PeerService; got != want {
+			t.Errorf("peer_service for %q = %q, want %q", peer, got, want)
+		}
+	}
+}
+
+func TestSelfNameFromEnv(t *testing.T) {
+	t.Setenv("SOVDEV_SELF_PEER_NAME", "SELV")
+	l, sink := newTestLogger(t, Config{ServiceName: "company-lookup"})
+
+	if err := l.Log(SOVDEV_LOGLEVELS.INFO, "TestSelfName", "Call", "SELV", nil, nil, nil, ""); err != nil {
+		t.Fatalf("Log: %v", err)
+	}
+	if got := sink.Entries()[len(sink.Entries())-1].PeerService; got != "company-lookup" {
+		t.Errorf("peer_service for SELV = %q, want the service name", got)
+	}
+	if got := CreatePeerServicesWithSelfName("", nil).INTERNAL; got != "INTERNAL" {
+		t.Errorf("empty self name gives INTERNAL = %q, want INTERNAL", got)
+	}
+}
+
+func TestAddPeerServiceWhileLogging(t *testing.T) {
+	l, sink := newTestLogger(t, Config{BoundMetricLabels: true, PeerServices: map[string]string{"BRREG": "SYS1234567"}})
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				peer := fmt.Sprintf("PEER%d", i%10)
+				if err := l.Log(SOVDEV_LOGLEVELS.INFO, "TestAddPeer", "Call", peer, nil, nil, nil, ""); err != nil {
+					t.Errorf("Log: %v", err)
+				}
+			}
+		}(w)
+	}
+	for i := 0; i < 100; i++ {
+		if err := l.AddPeerService(fmt.Sprintf("PEER%d", i%10), fmt.Sprintf("SYS%07d", i)); err != nil {
+			t.Errorf("AddPeerService: %v", err)
+		}
+	}
+	wg.Wait()
+
+	// Once added, the name resolves to its latest ID
+	if err := l.Log(SOVDEV_LOGLEVELS.INFO, "TestAddPeer", "Call", "PEER9", nil, nil, nil, ""); err != nil {
+		t.Fatalf("Log: %v", err)
+	}
+	if got := sink.Entries()[len(sink.Entries())-1].PeerService; got != "SYS0000099" {
+		t.Errorf("peer_service = %q, want SYS0000099", got)
+	}
+}
+
+func TestAddPeerServiceRejectsInvalidNames(t *testing.T) {
+	l, _ := newTestLogger(t, Config{})
+	for _, tt := range []struct{ name, id string }{{"", "SYS1234567"}, {"INTERNAL", "SYS1234567"}, {"BRREG", ""}} {
+		if err := l.AddPeerService(tt.name, tt.id); err == nil {
+			t.Errorf("AddPeerService(%q, %q) succeeded, want an error", tt.name, tt.id)
+		}
+	}
+}
+
+func TestNilPeerServices(t *testing.T) {
+	var ps *PeerServices
+
+	if got := ps.Get("BRREG"); got != "BRREG" {
+		t.Errorf("nil Get(BRREG) = %q, want the name as-is", got)
+	}
+	if id, ok := ps.Lookup("BRREG"); id != "" || ok {
+		t.Errorf("nil Lookup(BRREG) = %q, %v; want \"\", false", id, ok)
+	}
+	if got := ps.Resolve("SYS1234567"); got != "SYS1234567" {
+		t.Errorf("nil Resolve(SYS1234567) = %q, want it unchanged", got)
+	}
+	if err := ps.Validate(); err != nil {
+		t.Errorf("nil Validate() = %v", err)
+	}
+}
+
+func TestCreatePeerServicesWithNilDefinitions(t *testing.T) {
+	ps := CreatePeerServices(nil)
+
+	if ps.Mappings == nil {
+		t.Fatal("Mappings is nil; adding to it would panic")
+	}
+	ps.Mappings["BRREG"] = "SYS1234567"
+	if ps.INTERNAL != "INTERNAL" {
+		t.Errorf("INTERNAL = %q", ps.INTERNAL)
+	}
+	if id, ok := ps.Lookup("BRREG"); id != "SYS1234567" || !ok {
+		t.Errorf("Lookup(BRREG) = %q, %v after adding it", id, ok)
+	}
+	if id, ok := ps.Lookup("ALTINN"); id != "" || ok {
+		t.Errorf("Lookup(ALTINN) = %q, %v; want \"\", false", id, ok)
+	}
+}
@@ -0,0 +1,237 @@
+package sovdevlogger
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	// emailPattern finds email addresses in free text
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,}`)
+	// norwegianPhonePattern finds 8-digit Norwegian numbers (first digit 2-9), optionally
+	// with +47/0047 and grouped as "41 23 45 67" or "412 34 567"
+	norwegianPhonePattern = regexp.MustCompile(`(?:(?:\+|\b00)47[ -]?)?\b(?:[2-9]\d{7}|[2-9]\d{2}[ -]\d{2}[ -]\d{3}|[2-9]\d[ -]\d{2}[ -]\d{2}[ -]\d{2})\b`)
+	// fodselsnummerPattern finds 11-digit national identity numbers (fødselsnummer
+	// and D-nummer), optionally written "DDMMYY NNNNN"; matches are checked with
+	// validFodselsnummer
+	fodselsnummerPattern = regexp.MustCompile(`\b\d{6} ?\d{5}\b`)
+)
+
+// PII modes for Config.PIIMode
+const (
+	piiModeRedact = "redact"
+	piiModeHash   = "hash"
+)
+
+// piiHashLength is the number of hex characters kept from the HMAC; 64 bits is
+// plenty to group entries about one person
+const piiHashLength = 16
+
+// piiRule masks one category of personal data
+type piiRule struct {
+	category string // "email", "phone" or "fnr"
+	pattern  *regexp.Regexp
+	// normalize reduces a match to the canonical form that is hashed, so the same
+	// value written differently ("+47 412 34 567", "41234567") hashes the same
+	normalize func(match string) string
+	// valid rejects false positives the pattern cannot rule out; nil accepts all
+	valid func(normalized string) bool
+	// standalone rejects matches that are part of a longer token (a UUID segment,
+	// a decimal, a date), where the digits are not personal data
+	standalone bool
+}
+
+// piiRedactor masks the categories enabled in Config in message, payload and
+// exception strings, either with a fixed [REDACTED-<CATEGORY>] or, in hash mode,
+// with "<category>:<hex>" so entries about the same person can still be grouped.
+// A nil redactor leaves values unchanged.
+type piiRedactor struct {
+	rules []piiRule
+	salt  []byte // HMAC key in hash mode, nil when redacting
+}
+
+// newPIIRedactor returns a redactor for the enabled categories, or nil if none
+// are. Hash mode without a salt is an error: an unsalted hash of an 11-digit
+// number is reversed by trying them all.
+func newPIIRedactor(cfg Config) (*piiRedactor, error) {
+	var rules []piiRule
+	if cfg.RedactEmails || getEnvBool("SOVDEV_REDACT_EMAILS", false) {
+		rules = append(rules, piiRule{category: "email", pattern: emailPattern, normalize: strings.ToLower})
+	}
+	if cfg.RedactNationalIDs || getEnvBool("SOVDEV_REDACT_NATIONAL_IDS", false) {
+		rules = append(rules, piiRule{category: "fnr", pattern: fodselsnummerPattern, normalize: digitsOnly, valid: validFodselsnummer, standalone: true})
+	}
+	if cfg.RedactPhoneNumbers || getEnvBool("SOVDEV_REDACT_PHONE_NUMBERS", false) {
+		rules = append(rules, piiRule{category: "phone", pattern: norwegianPhonePattern, normalize: normalizePhone, standalone: true})
+	}
+
+	mode := strings.ToLower(cfg.PIIMode)
+	if mode == "" {
+		mode = strings.ToLower(getEnv("SOVDEV_PII_MODE", piiModeRedact))
+	}
+	salt := cfg.PIIHashSalt
+	if salt == "" {
+		salt = os.Getenv("SOVDEV_PII_HASH_SALT")
+	}
+
+	r := &piiRedactor{rules: rules}
+	switch mode {
+	case piiModeRedact:
+	case piiModeHash:
+		if salt == "" {
+			return nil, fmt.Errorf("PII hash mode requires a salt (PIIHashSalt or SOVDEV_PII_HASH_SALT)")
+		}
+		r.salt = []byte(salt)
+	default:
+		return nil, fmt.Errorf("invalid PII mode %q: must be %s or %s", mode, piiModeRedact, piiModeHash)
+	}
+
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	return r, nil
+}
+
+// redactString masks every enabled category in s
+func (r *piiRedactor) redactString(s string) string {
+	if r == nil {
+		return s
+	}
+	for _, rule := range r.rules {
+		s = r.apply(rule, s)
+	}
+	return s
+}
+
+// redactPayload returns a copy of a scrubbed payload (see scrubPayload) with
+// redactString applied to every string value
+func (r *piiRedactor) redactPayload(v interface{}) interface{} {
+	if r == nil {
+		return v
+	}
+	switch val := v.(type) {
+	case string:
+		return r.redactString(val)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = r.redactPayload(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = r.redactPayload(item)
+		}
+		return out
+	default:
+		return val
+	}
+}
+
+// replacement returns what a match is replaced with, or false to keep it
+func (r *piiRedactor) replacement(rule piiRule, match string) (string, bool) {
+	normalized := rule.normalize(match)
+	if rule.valid != nil && !rule.valid(normalized) {
+		return "", false
+	}
+	if r.salt == nil {
+		return "[REDACTED-" + strings.ToUpper(rule.category) + "]", true
+	}
+	mac := hmac.New(sha256.New, r.salt)
+	mac.Write([]byte(rule.category + ":" + normalized))
+	return rule.category + ":" + hex.EncodeToString(mac.Sum(nil))[:piiHashLength], true
+}
+
+func (r *piiRedactor) apply(rule piiRule, s string) string {
+	matches := rule.pattern.FindAllStringIndex(s, -1)
+	if matches == nil {
+		return s
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		if rule.standalone && !isStandalone(s, m[0], m[1]) {
+			continue
+		}
+		replacement, ok := r.replacement(rule, s[m[0]:m[1]])
+		if !ok {
+			continue
+		}
+		b.WriteString(s[last:m[0]])
+		b.WriteString(replacement)
+		last = m[1]
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// isStandalone reports whether s[start:end] is not glued to a neighbouring token
+// by a separator such as "-", ".", "/" or ":" (e.g. "41234567-89ab-..." or "3.41234567")
+func isStandalone(s string, start, end int) bool {
+	const separators = "-./:"
+	if start > 1 && strings.IndexByte(separators, s[start-1]) >= 0 {
+		if r, _ := utf8.DecodeLastRuneInString(s[:start-1]); unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return false
+		}
+	}
+	if end+1 < len(s) && strings.IndexByte(separators, s[end]) >= 0 {
+		if r, _ := utf8.DecodeRuneInString(s[end+1:]); unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// digitsOnly drops everything but ASCII digits
+func digitsOnly(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// normalizePhone reduces a Norwegian number to its 8 digits
+func normalizePhone(s string) string {
+	digits := digitsOnly(s)
+	digits = strings.TrimPrefix(digits, "00")
+	if len(digits) == 10 {
+		digits = strings.TrimPrefix(digits, "47")
+	}
+	return digits
+}
+
+// validFodselsnummer checks the two mod-11 control digits of an 11-digit
+// fødselsnummer or D-nummer, which rules out most other 11-digit numbers
+func validFodselsnummer(digits string) bool {
+	if len(digits) != 11 {
+		return false
+	}
+	d := make([]int, 11)
+	for i := range digits {
+		d[i] = int(digits[i] - '0')
+	}
+	control := func(weights []int) int {
+		sum := 0
+		for i, w := range weights {
+			sum += w * d[i]
+		}
+		k := 11 - sum%11
+		if k == 11 {
+			k = 0
+		}
+		return k
+	}
+	return control([]int{3, 7, 6, 1, 8, 9, 4, 5, 2}) == d[9] &&
+		control([]int{5, 4, 3, 2, 7, 6, 5, 4, 3, 2}) == d[10]
+}
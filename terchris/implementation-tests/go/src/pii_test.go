@@ -0,0 +1,147 @@
+package sovdevlogger
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// validFnr is a synthetic fødselsnummer with correct control digits
+const validFnr = "15076510013"
+
+func TestRedactStringCategories(t *testing.T) {
+	const text = "Contact ola.nordmann@example.no or +47 412 34 567, fnr " + validFnr +
+		", order 41234567-89ab-4cde-8f01-23456789abcd, amount 3.41234567, other 15076510014"
+
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{
+			"emails only",
+			Config{RedactEmails: true},
+			"Contact [REDACTED-EMAIL] or +47 412 34 567, fnr " + validFnr +
+				", order 41234567-89ab-4cde-8f01-23456789abcd, amount 3.41234567, other 15076510014",
+		},
+		{
+			"phones only",
+			Config{RedactPhoneNumbers: true},
+			"Contact ola.nordmann@example.no or [REDACTED-PHONE], fnr " + validFnr +
+				", order 41234567-89ab-4cde-8f01-23456789abcd, amount 3.41234567, other 15076510014",
+		},
+		{
+			"national ids only",
+			Config{RedactNationalIDs: true},
+			"Contact ola.nordmann@example.no or +47 412 34 567, fnr [REDACTED-FNR]" +
+				", order 41234567-89ab-4cde-8f01-23456789abcd, amount 3.41234567, other 15076510014",
+		},
+		{
+			"all categories",
+			Config{RedactEmails: true, RedactPhoneNumbers: true, RedactNationalIDs: true},
+			"Contact [REDACTED-EMAIL] or [REDACTED-PHONE], fnr [REDACTED-FNR]" +
+				", order 41234567-89ab-4cde-8f01-23456789abcd, amount 3.41234567, other 15076510014",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := newPIIRedactor(tt.cfg)
+			if err != nil {
+				t.Fatalf("newPIIRedactor: %v", err)
+			}
+			if got := r.redactString(text); got != tt.want {
+				t.Errorf("redactString:\n got %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPIIRedactorDisabledByDefault(t *testing.T) {
+	t.Setenv("SOVDEV_REDACT_EMAILS", "")
+	t.Setenv("SOVDEV_REDACT_PHONE_NUMBERS", "")
+	t.Setenv("SOVDEV_REDACT_NATIONAL_IDS", "")
+
+	r, err := newPIIRedactor(Config{})
+	if err != nil || r != nil {
+		t.Fatalf("newPIIRedactor(Config{}) = %v, %v; want nil, nil", r, err)
+	}
+	const text = "ola.nordmann@example.no 41234567"
+	if got := r.redactString(text); got != text {
+		t.Errorf("nil redactor changed %q to %q", text, got)
+	}
+}
+
+func TestPIIHashMode(t *testing.T) {
+	r, err := newPIIRedactor(Config{RedactEmails: true, RedactPhoneNumbers: true, PIIMode: "hash", PIIHashSalt: "pepper"})
+	if err != nil {
+		t.Fatalf("newPIIRedactor: %v", err)
+	}
+
+	hashed := regexp.MustCompile(`^phone:[0-9a-f]{16}$`)
+	a := r.redactString("+47 412 34 567")
+	if !hashed.MatchString(a) {
+		t.Fatalf("hashed phone = %q, want phone:<16 hex>", a)
+	}
+	for _, same := range []string{"41234567", "0047 41 23 45 67", "412-34-567"} {
+		if got := r.redactString(same); got != a {
+			t.Errorf("%q hashed to %q, want %q like +47 412 34 567", same, got, a)
+		}
+	}
+	if got := r.redactString("41234568"); got == a {
+		t.Errorf("different numbers hashed to the same %q", got)
+	}
+	if upper, lower := r.redactString("Ola@Example.no"), r.redactString("ola@example.no"); upper != lower {
+		t.Errorf("email case changes the hash: %q vs %q", upper, lower)
+	}
+
+	other, err := newPIIRedactor(Config{RedactPhoneNumbers: true, PIIMode: "hash", PIIHashSalt: "salt"})
+	if err != nil {
+		t.Fatalf("newPIIRedactor: %v", err)
+	}
+	if got := other.redactString("41234567"); got == a {
+		t.Errorf("hash does not depend on the salt: %q", got)
+	}
+}
+
+func TestPIIConfigErrors(t *testing.T) {
+	t.Setenv("SOVDEV_PII_HASH_SALT", "")
+
+	if _, err := newPIIRedactor(Config{RedactEmails: true, PIIMode: "hash"}); err == nil {
+		t.Error("hash mode without a salt succeeded; want an error")
+	}
+	if _, err := newPIIRedactor(Config{RedactEmails: true, PIIMode: "mask"}); err == nil {
+		t.Error("unknown PII mode succeeded; want an error")
+	}
+	if _, err := NewLogger(Config{ServiceName: "sovdev-test", RedactEmails: true, PIIMode: "hash"}); err == nil {
+		t.Error("NewLogger accepted hash mode without a salt")
+	}
+}
+
+func TestLogRedactsPII(t *testing.T) {
+	l, sink := newTestLogger(t, Config{RedactEmails: true, RedactPhoneNumbers: true})
+
+	err := l.Log(SOVDEV_LOGLEVELS.INFO, "TestPII", "Sent receipt to ola.nordmann@example.no", "",
+		map[string]interface{}{"contact": map[string]interface{}{"phone": "412 34 567", "orgnr": "971277882"}},
+		[]interface{}{"kari@example.com"}, nil, "")
+	if err != nil {
+		t.Fatalf("Log: %v", err)
+	}
+
+	entry := sink.Entries()[len(sink.Entries())-1]
+	data, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatalf("marshal entry: %v", err)
+	}
+	for _, leak := range []string{"ola.nordmann", "412 34 567", "kari@"} {
+		if strings.Contains(string(data), leak) {
+			t.Errorf("entry leaks %q: %s", leak, data)
+		}
+	}
+	if entry.Message != "Sent receipt to [REDACTED-EMAIL]" {
+		t.Errorf("message = %q", entry.Message)
+	}
+	if !strings.Contains(string(data), `"phone":"[REDACTED-PHONE]"`) || !strings.Contains(string(data), `"orgnr":"971277882"`) {
+		t.Errorf("input_json not redacted field by field: %s", data)
+	}
+}
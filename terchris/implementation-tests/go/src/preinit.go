@@ -0,0 +1,76 @@
+package sovdevlogger
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// preInitBufferSize caps entries logged before SovdevInitialize; later ones are dropped
+const preInitBufferSize = 100
+
+// preInitCall is a package-level log call waiting for the default logger
+type preInitCall struct {
+	at   time.Time
+	call func(ctx context.Context, l *Logger) error
+}
+
+var (
+	preInitMutex    sync.Mutex
+	preInitCalls    []preInitCall
+	preInitDropped  int
+	preInitWarned   bool
+	preInitReplayed bool
+)
+
+// bufferPreInit keeps a Sovdev* log call made before initialization so it can be
+// written, with its original timestamp, once SovdevInitialize runs. Libraries
+// logging from init() therefore lose nothing, and the "not initialized" warning
+// is printed only once.
+func bufferPreInit(call func(ctx context.Context, l *Logger) error) {
+	preInitMutex.Lock()
+	if preInitReplayed {
+		// Initialized between the caller's nil check and now
+		preInitMutex.Unlock()
+		logger := defaultLogger()
+		if logger == nil {
+			// ...and reset again since (SovdevReset)
+			bufferPreInit(call)
+			return
+		}
+		if err := call(context.Background(), logger); err != nil {
+			fmt.Printf("⚠️  Log entry rejected: %v\n", err)
+		}
+		return
+	}
+	defer preInitMutex.Unlock()
+
+	if !preInitWarned {
+		preInitWarned = true
+		fmt.Printf("⚠️  Logger not initialized. Call SovdevInitialize first. Buffering up to %d entries until then.\n", preInitBufferSize)
+	}
+	if len(preInitCalls) >= preInitBufferSize {
+		preInitDropped++
+		return
+	}
+	preInitCalls = append(preInitCalls, preInitCall{at: time.Now(), call: call})
+}
+
+// replayPreInit writes the buffered calls to l; called once the default logger is set
+func replayPreInit(l *Logger) {
+	preInitMutex.Lock()
+	calls, dropped := preInitCalls, preInitDropped
+	preInitCalls, preInitDropped = nil, 0
+	preInitReplayed = true
+	preInitMutex.Unlock()
+
+	for _, c := range calls {
+		if err := c.call(withEntryTime(context.Background(), c.at), l); err != nil {
+			fmt.Printf("⚠️  Log entry rejected: %v\n", err)
+		}
+	}
+	if dropped > 0 {
+		fmt.Printf("⚠️  Dropped %d entries logged before initialization (buffer holds %d)\n", dropped, preInitBufferSize)
+	}
+}
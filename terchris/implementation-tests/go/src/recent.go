@@ -0,0 +1,95 @@
+package sovdevlogger
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// recentBuffer is a fixed-size ring of the most recent entries; the oldest entry
+// is overwritten when it is full
+type recentBuffer struct {
+	mu      sync.Mutex
+	entries []StructuredLogEntry
+	next    int
+	full    bool
+}
+
+func newRecentBuffer(size int) *recentBuffer {
+	return &recentBuffer{entries: make([]StructuredLogEntry, size)}
+}
+
+func (b *recentBuffer) add(entry StructuredLogEntry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.entries[b.next] = entry
+	b.next = (b.next + 1) % len(b.entries)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// snapshot returns a copy of the buffered entries, oldest first
+func (b *recentBuffer) snapshot() []StructuredLogEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.full {
+		return append([]StructuredLogEntry(nil), b.entries[:b.next]...)
+	}
+	out := make([]StructuredLogEntry, 0, len(b.entries))
+	out = append(out, b.entries[b.next:]...)
+	return append(out, b.entries[:b.next]...)
+}
+
+// SovdevRecentLogs returns the last Config.RecentLogsSize entries written by the
+// default logger, oldest first, or nil when the buffer is off
+func SovdevRecentLogs() []StructuredLogEntry {
+	logger := defaultLogger()
+	if logger == nil {
+		return nil
+	}
+	return logger.RecentLogs()
+}
+
+// SovdevDebugHandler returns an http.Handler serving SovdevRecentLogs as a JSON
+// array, for mounting at e.g. /debug/sovdev/logs. Entries are already sanitized,
+// but they are still application logs: do not expose the handler publicly.
+// GET responds with 404 while the buffer is off.
+//
+// POST lowers the min level to DEBUG for a bounded time (see Logger.EnableDebugFor),
+// e.g. during an incident:
+//
+//	curl -X POST -H "Authorization: Bearer $SOVDEV_DEBUG_TOKEN" \
+//	    "http://localhost:8080/debug/sovdev/logs?duration=15m&by=alice"
+//
+// POST is refused unless Config.DebugToken (or SOVDEV_DEBUG_TOKEN) is set.
+func SovdevDebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger := defaultLogger()
+
+		if r.Method == http.MethodPost {
+			if logger == nil {
+				http.Error(w, "logger not initialized", http.StatusServiceUnavailable)
+				return
+			}
+			logger.handleDebugLevel(w, r)
+			return
+		}
+		if logger == nil || logger.recent == nil {
+			http.Error(w, "recent log buffer not enabled", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(logger.RecentLogs())
+	})
+}
+
+// RecentLogs returns this logger's buffered entries, oldest first (see SovdevRecentLogs)
+func (l *Logger) RecentLogs() []StructuredLogEntry {
+	if l.recent == nil {
+		return nil
+	}
+	return l.recent.snapshot()
+}
@@ -0,0 +1,221 @@
+package sovdevlogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// urlPattern finds http(s) URLs embedded in free text
+var urlPattern = regexp.MustCompile(`https?://[^\s"'<>]+`)
+
+// sensitiveQueryParams are query parameter names whose values are masked in URLs
+var sensitiveQueryParams = map[string]bool{
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+	"id_token":      true,
+	"api_key":       true,
+	"apikey":        true,
+	"api-key":       true,
+	"key":           true,
+	"code":          true,
+	"sig":           true,
+	"signature":     true,
+	"secret":        true,
+	"client_secret": true,
+	"password":      true,
+	"pwd":           true,
+	"auth":          true,
+}
+
+// sanitizeURLs masks sensitive query parameters and userinfo passwords in any
+// http(s) URL found in s, keeping scheme, host, path and other parameters intact
+// Example: "GET https://api.example.com/x?id=1&token=abc" -> "GET https://api.example.com/x?id=1&token=REDACTED"
+func sanitizeURLs(s string) string {
+	if !strings.Contains(s, "http") {
+		return s
+	}
+	return urlPattern.ReplaceAllStringFunc(s, sanitizeURL)
+}
+
+func sanitizeURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+
+	changed := false
+	if u.User != nil {
+		if _, hasPassword := u.User.Password(); hasPassword {
+			u.User = url.UserPassword(u.User.Username(), "REDACTED")
+			changed = true
+		}
+	}
+
+	// Rewrite the query by hand so parameter order and encoding are preserved
+	if u.RawQuery != "" {
+		params := strings.Split(u.RawQuery, "&")
+		for i, param := range params {
+			name, _, hasValue := strings.Cut(param, "=")
+			if !hasValue {
+				continue
+			}
+			if decoded, err := url.QueryUnescape(name); err == nil {
+				name = decoded
+			}
+			if sensitiveQueryParams[strings.ToLower(name)] {
+				params[i] = param[:strings.Index(param, "=")+1] + "REDACTED"
+				changed = true
+			}
+		}
+		u.RawQuery = strings.Join(params, "&")
+	}
+
+	if !changed {
+		return raw
+	}
+	return u.String()
+}
+
+// escapeControlChars replaces control characters with visible escape sequences
+// (\n, \r, \t, \u001b, ...) so a value can never split or forge a line of
+// PrettyFormatter output. Entries keep the original text: JSON and OTLP encode
+// it on their own, and LogfmtFormatter quotes it (see logfmtValue).
+func escapeControlChars(s string) string {
+	if strings.IndexFunc(s, isControlChar) < 0 {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s) + 8)
+	for _, r := range s {
+		switch {
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case isControlChar(r):
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// isControlChar matches C0/C1 control characters and the Unicode line/paragraph separators
+func isControlChar(r rune) bool {
+	return r < 0x20 || (r >= 0x7f && r <= 0x9f) || r == '\u2028' || r == '\u2029'
+}
+
+// scrubString applies all string sanitizers to a single value
+func scrubString(s string) string {
+	return sanitizeURLs(s)
+}
+
+// scrubPayload returns a copy of an input/response payload with scrubString
+// applied to every string value. Maps and slices are copied, never mutated;
+// structs and other types are converted to their JSON representation first.
+// Already-encoded JSON (json.RawMessage, or a []byte holding a JSON object or
+// array such as a raw response body) is embedded as structure instead of a
+// base64 or escaped string.
+func scrubPayload(v interface{}) interface{} {
+	switch val := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return scrubString(val)
+	case json.RawMessage:
+		if generic, ok := decodeJSONPayload(val); ok {
+			return scrubPayload(generic)
+		}
+		// Invalid JSON would make the whole entry unmarshalable; keep it as text
+		return scrubString(string(val))
+	case []byte:
+		if trimmed := bytes.TrimSpace(val); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+			if generic, ok := decodeJSONPayload(val); ok {
+				return scrubPayload(generic)
+			}
+		}
+		return val
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64,
+		float32, float64, json.Number:
+		return val
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = scrubPayload(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = scrubPayload(item)
+		}
+		return out
+	default:
+		// Structs, typed maps and slices: round-trip through JSON to reach the strings
+		data, err := json.Marshal(val)
+		if err != nil {
+			return val
+		}
+		generic, ok := decodeJSONPayload(data)
+		if !ok {
+			return val
+		}
+		return scrubPayload(generic)
+	}
+}
+
+// decodeJSONPayload decodes a single JSON value, keeping numbers as json.Number
+// so large integers stay exact
+func decodeJSONPayload(data []byte) (interface{}, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil || decoder.More() {
+		return nil, false
+	}
+	return generic, true
+}
+
+// marshalJSON is json.Marshal without HTML escaping, so payloads containing
+// <, > or & (markup, query strings) are logged as written instead of \u003c
+func marshalJSON(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// encodePayload marshals a scrubbed payload to json.RawMessage, so each output
+// reuses the bytes instead of encoding the structure again. A payload that
+// cannot be marshaled is returned unchanged and fails in the outputs as before.
+func encodePayload(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	data, err := marshalJSON(v)
+	if err != nil {
+		return v
+	}
+	return json.RawMessage(data)
+}
+
+// payloadJSON returns the JSON for an entry payload, reusing the bytes of an
+// encoded one (see encodePayload)
+func payloadJSON(v interface{}) ([]byte, error) {
+	if raw, ok := v.(json.RawMessage); ok {
+		return raw, nil
+	}
+	return marshalJSON(v)
+}
@@ -0,0 +1,120 @@
+package sovdevlogger
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+)
+
+// signalShutdownTimeout bounds the flush performed by SovdevHandleSignals and the
+// shutdown in SovdevReset. Kept well under the default Kubernetes termination
+// grace period (30s).
+const signalShutdownTimeout = 5 * time.Second
+
+// SovdevShutdown flushes and stops the default logger's OpenTelemetry providers.
+// Call it (or SovdevFlush) before the process exits. Repeated calls are safe.
+func SovdevShutdown(ctx context.Context) error {
+	logger := defaultLogger()
+	if logger == nil {
+		return nil
+	}
+	return logger.Shutdown(ctx)
+}
+
+// SovdevReset shuts down the default logger and returns the package to its
+// uninitialized state, so the next SovdevInitialize starts from scratch (new
+// session, providers and counters). Intended for tests only, e.g.
+// t.Cleanup(sovdevlogger.SovdevReset); services should use SovdevShutdown.
+// Safe to call when never initialized.
+func SovdevReset() {
+	previous := resetGlobal()
+	if previous == nil {
+		return
+	}
+
+	// Shut down outside globalMutex, so concurrent Sovdev* calls are not held up
+	// by the flush; they already see the uninitialized state
+	ctx, cancel := context.WithTimeout(context.Background(), signalShutdownTimeout)
+	defer cancel()
+	if err := previous.Shutdown(ctx); err != nil {
+		fmt.Printf("⚠️  Logger shutdown warning: %v\n", err)
+	}
+}
+
+// resetGlobal detaches the default logger and returns the package to its
+// uninitialized state, returning the detached logger (nil if there was none)
+func resetGlobal() *Logger {
+	globalMutex.Lock()
+	defer globalMutex.Unlock()
+
+	// Reset the pre-init buffer before clearing the logger, so a concurrent call
+	// that sees a nil logger buffers instead of replaying into it
+	preInitMutex.Lock()
+	preInitCalls, preInitDropped = nil, 0
+	preInitWarned, preInitReplayed = false, false
+	preInitMutex.Unlock()
+
+	previous := globalLogger
+	globalLogger = nil
+	initialized = false
+	initConfig = Config{}
+
+	// Detach the OTEL globals from the old logger
+	otel.SetTracerProvider(tracenoop.NewTracerProvider())
+	otel.SetMeterProvider(metricnoop.NewMeterProvider())
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) { log.Print(err) }))
+	otel.SetLogger(logr.Discard())
+
+	return previous
+}
+
+// SovdevHandleSignals installs SIGINT/SIGTERM handlers and returns a context that
+// is cancelled when one arrives. On the first signal the returned context is
+// cancelled and buffered telemetry is flushed with a 5 second timeout, so it is
+// not lost if the process is killed before it exits. The logger keeps running:
+// the application can still log while it winds down, and shuts the logger down
+// itself on its way out.
+//
+//	ctx := sovdevlogger.SovdevHandleSignals(context.Background())
+//	runJob(ctx) // return promptly once ctx is done
+//	sovdevlogger.SovdevShutdown(context.Background()) // flushes the final entries
+//
+// Interaction with existing signal handling: signal.Notify delivers to every
+// registered channel, so the caller's own handlers keep working. After the first
+// signal the handler is removed, so a second SIGINT/SIGTERM falls back to the
+// caller's handlers or Go's default (immediate exit).
+func SovdevHandleSignals(ctx context.Context) context.Context {
+	ctx, cancel := context.WithCancel(ctx)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		defer signal.Stop(signals)
+
+		select {
+		case sig := <-signals:
+			fmt.Printf("🛑 Received %v, flushing sovdev-logger\n", sig)
+			cancel()
+
+			flushCtx, flushCancel := context.WithTimeout(context.Background(), signalShutdownTimeout)
+			defer flushCancel()
+			if err := SovdevFlushContext(flushCtx); err != nil {
+				fmt.Printf("⚠️  Flush warning: %v\n", err)
+			}
+		case <-ctx.Done():
+			cancel()
+		}
+	}()
+
+	return ctx
+}
@@ -0,0 +1,145 @@
+package sovdevlogger
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+)
+
+func TestResetReturnsToUninitializedState(t *testing.T) {
+	initTestDefault(t, testConfig("1.0.0"))
+	session := SovdevSessionID()
+
+	SovdevReset()
+	if got := SovdevSessionID(); got != "" {
+		t.Errorf("session ID after reset = %q, want empty", got)
+	}
+
+	// A different config is accepted again, with a new session
+	if err := SovdevInitializeWithConfig(testConfig("2.0.0")); err != nil {
+		t.Fatalf("initialize after reset: %v", err)
+	}
+	if got := SovdevSessionID(); got == "" || got == session {
+		t.Errorf("session ID after re-initialize = %q, want a new one (was %q)", got, session)
+	}
+}
+
+// Run with -race: package functions must not race with SovdevReset
+func TestPackageFunctionsDuringReset(t *testing.T) {
+	initTestDefault(t, testConfig("1.0.0"))
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				SovdevLog(SOVDEV_LOGLEVELS.INFO, "TestReset", "Logging", "", nil, nil, nil, "")
+				SovdevLevelEnabled(SOVDEV_LOGLEVELS.DEBUG)
+				_ = SovdevServiceInfo()
+				_ = SovdevFlush()
+			}
+		}()
+	}
+
+	for i := 0; i < 5; i++ {
+		SovdevReset()
+		if err := SovdevInitializeWithConfig(testConfig("1.0.0")); err != nil {
+			t.Errorf("initialize after reset: %v", err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
+
+func TestRegisterProvidersWithoutOTEL(t *testing.T) {
+	t.Cleanup(SovdevReset)
+
+	// A logger whose OpenTelemetry initialization failed has no providers
+	registerOTELProviders(&Logger{})
+
+	_, span := otel.Tracer("after-failed-init").Start(context.Background(), "span")
+	span.End()
+	counter, err := otel.Meter("after-failed-init").Int64Counter("count")
+	if err != nil {
+		t.Fatalf("Int64Counter: %v", err)
+	}
+	counter.Add(context.Background(), 1)
+}
+
+func TestShutdownStopsOTELInternalWorker(t *testing.T) {
+	sink := &recordingSink{}
+	cfg := testConfig("1.0.0")
+	cfg.Sinks = []Sink{sink}
+	initTestDefault(t, cfg)
+	queue := defaultLogger().otelInternal
+	if queue == nil {
+		t.Fatal("no OTEL internal worker started")
+	}
+
+	otel.Handle(errors.New("queued before shutdown"))
+	if err := SovdevShutdown(context.Background()); err != nil {
+		t.Logf("SovdevShutdown: %v", err) // testEnv has no collector
+	}
+	select {
+	case <-queue.done:
+	default:
+		t.Fatal("OTEL internal worker still running after shutdown")
+	}
+	logged := false
+	for _, entry := range sink.Entries() {
+		logged = logged || entry.LogType == logTypeOTELInternal
+	}
+	if !logged {
+		t.Error("diagnostic queued before shutdown was not logged")
+	}
+
+	// The handler stays installed until the next initialization; it must not
+	// send on the closed queue
+	otel.Handle(errors.New("after shutdown"))
+}
+
+func TestHandleSignalsKeepsLoggerRunning(t *testing.T) {
+	testEnv(t)
+	collector := newTestCollector(t)
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", collector.URL)
+	t.Cleanup(SovdevReset)
+	if err := SovdevInitializeWithConfig(testConfig("1.0.0")); err != nil {
+		t.Fatalf("SovdevInitializeWithConfig: %v", err)
+	}
+
+	ctx := SovdevHandleSignals(context.Background())
+	self, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := self.Signal(syscall.SIGTERM); err != nil {
+		t.Skipf("cannot signal the test process: %v", err)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("context not cancelled by SIGTERM")
+	}
+
+	// The application's final entries after the signal still reach the collector
+	SovdevLog(SOVDEV_LOGLEVELS.INFO, "TestHandleSignals", "Winding down", "", nil, nil, nil, "")
+	if err := SovdevShutdown(context.Background()); err != nil {
+		t.Fatalf("SovdevShutdown: %v", err)
+	}
+	if exportedLogRecord(t, collector, "Winding down") == nil {
+		t.Error("entry logged after the signal was not exported")
+	}
+}
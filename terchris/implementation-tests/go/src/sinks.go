@@ -0,0 +1,237 @@
+package sovdevlogger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Sink is an output for log entries. Every entry that passes the min level and
+// deduplication is written to each sink in turn, one entry at a time, so all
+// sinks see entries in the same order; Close is called once when the logger
+// shuts down. Sinks must be safe for concurrent use (Flush and Close may run
+// during a Write), must not log through the same logger from Write, and should
+// not block, since other log calls wait meanwhile: slow outputs belong behind a
+// queue, like SplunkHECSink. A sink that buffers
+// can also implement Flush(ctx context.Context) error to be flushed by SovdevFlush.
+type Sink interface {
+	Write(entry StructuredLogEntry) error
+	Close() error
+}
+
+// sinkFlusher is implemented by sinks that buffer entries
+type sinkFlusher interface {
+	Flush(ctx context.Context) error
+}
+
+// contextWriter is implemented by sinks that use the caller's context, e.g. the
+// OTLP sink linking the record to the active span
+type contextWriter interface {
+	WriteContext(ctx context.Context, entry StructuredLogEntry) error
+}
+
+// contextCloser is implemented by sinks whose Close should respect the
+// shutdown deadline
+type contextCloser interface {
+	CloseContext(ctx context.Context) error
+}
+
+// namedSink is a configured sink with the name it is counted under in Stats
+type namedSink struct {
+	name  string
+	label string // used in failure messages
+	sink  Sink
+	// accepts limits the sink to some entries (ERROR/FATAL for error.log, audit
+	// entries for the audit log); nil accepts all. It may only look at the level
+	// and log type, see wants.
+	accepts func(entry StructuredLogEntry) bool
+	// reportAll prints every failed write instead of a throttled warning
+	reportAll bool
+	// minSeverity skips entries below this severity number (Config.ConsoleMinLevel
+	// etc.); 0 accepts all
+	minSeverity int
+}
+
+// writeSink hands entry to the sink and updates Stats; failures go to stdout only,
+// since logging them would recurse
+func (l *Logger) writeSink(ctx context.Context, s namedSink, entry StructuredLogEntry) {
+	if !s.wants(entry) {
+		return
+	}
+
+	var err error
+	if w, ok := s.sink.(contextWriter); ok {
+		err = w.WriteContext(ctx, entry)
+	} else {
+		err = s.sink.Write(entry)
+	}
+	if err == nil {
+		l.stats.wrote(s.name)
+		return
+	}
+
+	l.stats.writeFailed(s.name)
+	if s.reportAll {
+		fmt.Printf("❌ %s write failed: %v\n", s.label, err)
+	} else if ok, _ := l.selfLogThrottle.allow(s.name); ok {
+		fmt.Printf("⚠️  %s write failed: %v\n", s.label, err)
+	}
+}
+
+// closeSink closes a sink within ctx's deadline where the sink supports it
+func closeSink(ctx context.Context, s Sink) error {
+	if c, ok := s.(contextCloser); ok {
+		return c.CloseContext(ctx)
+	}
+	return s.Close()
+}
+
+// sinkMinSeverity returns the severity number of an output's min level, set in
+// Config or the env var key; 0 (no extra filter) when unset or invalid
+func sinkMinSeverity(level SovdevLogLevel, key, label string) int {
+	if level == "" {
+		level = SovdevLogLevel(strings.ToLower(os.Getenv(key)))
+	}
+	if level == "" {
+		return 0
+	}
+	if !isValidLevel(level) {
+		fmt.Printf("⚠️  Invalid %s min level %q, not filtering %s\n", label, level, label)
+		return 0
+	}
+	return mapToSeverityNumber(level)
+}
+
+// wants reports whether the sink takes entries with entry's level and log type
+func (s namedSink) wants(entry StructuredLogEntry) bool {
+	if s.accepts != nil && !s.accepts(entry) {
+		return false
+	}
+	return s.minSeverity == 0 || mapToSeverityNumber(SovdevLogLevel(entry.Level)) >= s.minSeverity
+}
+
+// anySinkWants reports whether any sink or the recent-entries buffer would
+// receive an entry of this level and log type
+func (l *Logger) anySinkWants(level SovdevLogLevel, logType SovdevLogType) bool {
+	if l.recent != nil {
+		return true
+	}
+	probe := StructuredLogEntry{Level: string(level), LogType: logType}
+	for _, s := range l.sinks {
+		if s.wants(probe) {
+			return true
+		}
+	}
+	return false
+}
+
+// isErrorEntry reports whether entry goes to error.log
+func isErrorEntry(entry StructuredLogEntry) bool {
+	return entry.Level == string(SOVDEV_LOGLEVELS.ERROR) || entry.Level == string(SOVDEV_LOGLEVELS.FATAL)
+}
+
+// isNotErrorEntry is the complement of isErrorEntry
+func isNotErrorEntry(entry StructuredLogEntry) bool {
+	return !isErrorEntry(entry)
+}
+
+// isAuditEntry reports whether entry goes to the audit log
+func isAuditEntry(entry StructuredLogEntry) bool {
+	return entry.LogType == SOVDEV_LOGTYPES.AUDIT
+}
+
+// lineSink writes each entry as one line rendered by formatter (dev.log,
+// error.log and the console)
+type lineSink struct {
+	out       *log.Logger
+	closer    io.Closer // nil for stdout and stderr, which are not ours to close
+	formatter Formatter
+}
+
+func newLineSink(w io.Writer, formatter Formatter) *lineSink {
+	s := &lineSink{out: log.New(w, "", 0), formatter: formatter}
+	if closer, ok := w.(io.Closer); ok && w != os.Stdout && w != os.Stderr {
+		s.closer = closer
+	}
+	return s
+}
+
+func (s *lineSink) Write(entry StructuredLogEntry) error {
+	data, err := s.formatter.Format(entry)
+	if err != nil {
+		return fmt.Errorf("format log entry: %w", err)
+	}
+	return s.out.Output(2, string(data))
+}
+
+func (s *lineSink) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
+
+// syncingFile fsyncs a rotating log file after every write (Config.FileSyncEachWrite).
+// lumberjack keeps its *os.File private, so the file is reopened by name for the
+// fsync, which flushes data written through any descriptor. Writes are serialized
+// by the lineSink's log.Logger, so a rotation cannot happen between write and sync.
+type syncingFile struct {
+	*lumberjack.Logger
+}
+
+func (f syncingFile) Write(p []byte) (int, error) {
+	n, err := f.Logger.Write(p)
+	if err != nil {
+		return n, err
+	}
+	file, err := os.OpenFile(f.Filename, os.O_WRONLY, 0)
+	if err != nil {
+		return n, err
+	}
+	defer file.Close()
+	return n, file.Sync()
+}
+
+// otlpSink emits entries through the logger's OTLP log provider. Close is a
+// no-op; the provider is shut down with the rest of the OTEL pipeline.
+type otlpSink struct {
+	l *Logger
+}
+
+func (s otlpSink) Write(entry StructuredLogEntry) error {
+	return s.WriteContext(context.Background(), entry)
+}
+
+func (s otlpSink) WriteContext(ctx context.Context, entry StructuredLogEntry) error {
+	s.l.writeToOTLP(ctx, SovdevLogLevel(entry.Level), entry)
+	return nil
+}
+
+func (s otlpSink) Close() error { return nil }
+
+// splunkSinkAdapter fits SplunkHECSink, whose Close and Flush take a context,
+// to Sink
+type splunkSinkAdapter struct {
+	s *SplunkHECSink
+}
+
+func (a splunkSinkAdapter) Write(entry StructuredLogEntry) error {
+	return a.s.WriteEntry(SovdevLogLevel(entry.Level), entry)
+}
+
+func (a splunkSinkAdapter) Close() error {
+	return a.s.Close(context.Background())
+}
+
+func (a splunkSinkAdapter) CloseContext(ctx context.Context) error {
+	return a.s.Close(ctx)
+}
+
+func (a splunkSinkAdapter) Flush(ctx context.Context) error {
+	return a.s.Flush(ctx)
+}
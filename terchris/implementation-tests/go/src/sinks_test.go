@@ -0,0 +1,521 @@
+package sovdevlogger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+func TestSinksSeeEntriesInSameOrder(t *testing.T) {
+	second := &recordingSink{}
+	l, first := newTestLogger(t, Config{Sinks: []Sink{second}})
+
+	const workers, perWorker = 8, 50
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				msg := fmt.Sprintf("worker %d entry %d", w, i)
+				if err := l.Log(SOVDEV_LOGLEVELS.INFO, "TestSinks", msg, "", nil, nil, nil, ""); err != nil {
+					t.Errorf("Log: %v", err)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	a, b := first.Entries(), second.Entries()
+	if len(a) != workers*perWorker || len(b) != workers*perWorker {
+		t.Fatalf("got %d and %d entries, want %d each", len(a), len(b), workers*perWorker)
+	}
+	for i := range a {
+		if a[i].EventID != b[i].EventID {
+			t.Fatalf("entry %d differs between sinks: %q vs %q", i, a[i].Message, b[i].Message)
+		}
+	}
+}
+
+func TestTruncatedOTLPAttributesDoNotDeadlock(t *testing.T) {
+	l, sink := newTestLogger(t, Config{MinLevel: SOVDEV_LOGLEVELS.TRACE, MaxAttributeValueLength: 100})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- l.Log(SOVDEV_LOGLEVELS.INFO, "TestTruncation", "Large payload", "",
+			map[string]interface{}{"blob": strings.Repeat("x", 5000)}, nil, nil, "")
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Log: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Log with a truncated OTLP attribute did not return")
+	}
+
+	var reported int
+	for _, e := range sink.Entries() {
+		if e.FunctionName == "writeToOTLP" {
+			reported++
+		}
+	}
+	if reported != 1 {
+		t.Errorf("truncation reported %d times, want 1", reported)
+	}
+}
+
+func TestConsoleAndFileFormattersAreIndependent(t *testing.T) {
+	dir := chdirTemp(t)
+	testEnv(t)
+	t.Setenv("LOG_TO_CONSOLE", "true")
+	t.Setenv("LOG_TO_FILE", "true")
+	t.Setenv("LOG_FILE_PATH", filepath.Join(dir, "dev.log"))
+
+	// The console sink writes to whatever os.Stdout is when the logger is created
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	l, err := NewLogger(Config{
+		ServiceName:       "sovdev-test",
+		ServiceVersion:    "1.0.0",
+		ConsoleFormatter:  PrettyFormatter{},
+		FileFormatter:     JSONFormatter{},
+		OTLPRetryDisabled: true,
+		OTLPTimeout:       100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	if err := l.Log(SOVDEV_LOGLEVELS.INFO, "TestFormatters", "Formatted twice", "", nil, nil, nil, ""); err != nil {
+		t.Fatalf("Log: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_ = l.Shutdown(ctx)
+	w.Close()
+	os.Stdout = stdout
+
+	console, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.ReadFile(filepath.Join(dir, "dev.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	consoleLine := lineContaining(string(console), "Formatted twice")
+	fileLine := lineContaining(string(file), "Formatted twice")
+	if consoleLine == "" || fileLine == "" {
+		t.Fatalf("entry missing; console:\n%s\nfile:\n%s", console, file)
+	}
+	if !strings.Contains(consoleLine, "INFO") || strings.HasPrefix(consoleLine, "{") {
+		t.Errorf("console line is not pretty: %s", consoleLine)
+	}
+	var entry StructuredLogEntry
+	if err := json.Unmarshal([]byte(fileLine), &entry); err != nil || entry.Message != "Formatted twice" {
+		t.Errorf("file line is not the JSON entry (%v): %s", err, fileLine)
+	}
+}
+
+// lineContaining returns the first line of s containing substr, or ""
+func lineContaining(s, substr string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if strings.Contains(line, substr) {
+			return line
+		}
+	}
+	return ""
+}
+
+func TestErrorLogCanBeDisabled(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		env     string
+		wantLog bool
+	}{
+		{"enabled by default", Config{}, "", true},
+		{"disabled in config", Config{DisableErrorLog: true}, "", false},
+		{"disabled by env", Config{}, "false", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SOVDEV_ERROR_LOG_ENABLED", tt.env)
+			l, dir := newFileTestLogger(t, tt.cfg)
+
+			if err := l.Log(SOVDEV_LOGLEVELS.ERROR, "TestErrorLog", "Lookup failed", "", nil, nil, errors.New("boom"), ""); err != nil {
+				t.Fatalf("Log: %v", err)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			_ = l.Shutdown(ctx)
+
+			if data, err := os.ReadFile(filepath.Join(dir, "dev.log")); err != nil || !strings.Contains(string(data), "Lookup failed") {
+				t.Errorf("dev.log does not have the entry (%v): %s", err, data)
+			}
+			_, err := os.Stat(filepath.Join(dir, "error.log"))
+			if exists := err == nil; exists != tt.wantLog {
+				t.Errorf("error.log exists: %v, want %v", exists, tt.wantLog)
+			}
+		})
+	}
+}
+
+// captureFile points *f (os.Stdout or os.Stderr) at a pipe until the returned
+// function is called, which restores it and returns what was written
+func captureFile(t *testing.T, f **os.File) func() string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := *f
+	*f = w
+	done := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- string(data)
+	}()
+
+	var captured *string
+	restore := func() string {
+		if captured == nil {
+			*f = orig
+			w.Close()
+			s := <-done
+			captured = &s
+		}
+		return *captured
+	}
+	t.Cleanup(func() { restore() })
+	return restore
+}
+
+func TestConsoleErrorToStderr(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           Config
+		env           string
+		errorToStderr bool
+	}{
+		{"default", Config{}, "", false},
+		{"config", Config{ConsoleErrorToStderr: true}, "", true},
+		{"env", Config{}, "true", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testEnv(t)
+			t.Setenv("LOG_TO_CONSOLE", "true")
+			t.Setenv("SOVDEV_CONSOLE_ERROR_TO_STDERR", tt.env)
+			stdout := captureFile(t, &os.Stdout)
+			stderr := captureFile(t, &os.Stderr)
+
+			l, _ := buildTestLogger(t, tt.cfg)
+			for _, level := range []SovdevLogLevel{SOVDEV_LOGLEVELS.INFO, SOVDEV_LOGLEVELS.WARN, SOVDEV_LOGLEVELS.ERROR, SOVDEV_LOGLEVELS.FATAL} {
+				if err := l.Log(level, "TestStderr", "Entry at "+string(level), "", nil, nil, nil, ""); err != nil {
+					t.Fatalf("Log: %v", err)
+				}
+			}
+			out, errOut := stdout(), stderr()
+
+			for _, level := range []string{"info", "warn", "error", "fatal"} {
+				onStderr := tt.errorToStderr && (level == "error" || level == "fatal")
+				want, other := out, errOut
+				if onStderr {
+					want, other = errOut, out
+				}
+				if lineContaining(want, "Entry at "+level) == "" {
+					t.Errorf("%s entry missing from %s; stdout:\n%s\nstderr:\n%s", level, streamName(onStderr), out, errOut)
+				}
+				if lineContaining(other, "Entry at "+level) != "" {
+					t.Errorf("%s entry also written to %s", level, streamName(!onStderr))
+				}
+			}
+		})
+	}
+}
+
+func streamName(stderr bool) string {
+	if stderr {
+		return "stderr"
+	}
+	return "stdout"
+}
+
+func TestFileSyncEachWrite(t *testing.T) {
+	for _, name := range []string{"config", "env"} {
+		t.Run(name, func(t *testing.T) {
+			cfg := Config{FileSyncEachWrite: name == "config"}
+			if name == "env" {
+				t.Setenv("SOVDEV_FILE_SYNC_EACH_WRITE", "true")
+			}
+			l, dir := newFileTestLogger(t, cfg)
+
+			for _, s := range l.sinks {
+				if ls, ok := s.sink.(*lineSink); ok && (s.name == "file" || s.name == "error_file") {
+					if _, ok := ls.out.Writer().(syncingFile); !ok {
+						t.Errorf("%s sink writes through %T, want syncingFile", s.name, ls.out.Writer())
+					}
+				}
+			}
+
+			if err := l.Log(SOVDEV_LOGLEVELS.ERROR, "TestSync", "Durable entry", "", nil, nil, errors.New("boom"), ""); err != nil {
+				t.Fatalf("Log: %v", err)
+			}
+			// Read before Shutdown: the entry must already be on disk
+			for _, file := range []string{"dev.log", "error.log"} {
+				data, err := os.ReadFile(filepath.Join(dir, file))
+				if err != nil || !strings.Contains(string(data), "Durable entry") {
+					t.Errorf("%s does not have the entry right after the log call (%v): %s", file, err, data)
+				}
+			}
+		})
+	}
+}
+
+func TestFileSyncOffByDefault(t *testing.T) {
+	t.Setenv("SOVDEV_FILE_SYNC_EACH_WRITE", "")
+	l, _ := newFileTestLogger(t, Config{})
+	for _, s := range l.sinks {
+		if ls, ok := s.sink.(*lineSink); ok && s.name == "file" {
+			if _, ok := ls.out.Writer().(syncingFile); ok {
+				t.Error("file sink syncs each write without FileSyncEachWrite")
+			}
+			return
+		}
+	}
+	t.Fatal("no file sink")
+}
+
+// countingIDs is an IDGenerator counting the IDs it hands out
+type countingIDs struct{ n *atomic.Int64 }
+
+func (g countingIDs) NewID() string {
+	g.n.Add(1)
+	return RandomIDGenerator{}.NewID()
+}
+
+func TestEntryNotBuiltWithoutReceivingSink(t *testing.T) {
+	testEnv(t)
+	collector := newTestCollector(t)
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", collector.URL)
+	ids := &atomic.Int64{}
+	l := startTestLogger(t, Config{OTLPMinLevel: SOVDEV_LOGLEVELS.WARN, IDGenerator: countingIDs{ids}})
+
+	before := ids.Load()
+	_ = l.Log(SOVDEV_LOGLEVELS.INFO, "TestNoSink", "Below every output", "", nil, nil, nil, "")
+	if got := ids.Load() - before; got != 0 {
+		t.Errorf("%d IDs generated for an entry no output takes, want none", got)
+	}
+	_ = l.Log(SOVDEV_LOGLEVELS.WARN, "TestNoSink", "Exported", "", nil, nil, nil, "")
+	if got := ids.Load() - before; got != 1 {
+		t.Errorf("%d IDs generated for an exported entry, want 1", got)
+	}
+
+	if err := l.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	var total int64
+	for _, dp := range exportedMetrics(t, collector)["sovdev.operations.total"].GetSum().GetDataPoints() {
+		total += dp.GetAsInt()
+	}
+	if total != 2 {
+		t.Errorf("sovdev.operations.total = %d, want both entries counted", total)
+	}
+}
+
+func TestPerSinkMinLevel(t *testing.T) {
+	testEnv(t)
+	dir := chdirTemp(t)
+	collector := newTestCollector(t)
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", collector.URL)
+	t.Setenv("LOG_TO_CONSOLE", "true")
+	t.Setenv("LOG_TO_FILE", "true")
+	t.Setenv("LOG_FILE_PATH", filepath.Join(dir, "dev.log"))
+	t.Setenv("SOVDEV_ERROR_LOG_ENABLED", "false")
+	stdout := captureFile(t, &os.Stdout)
+
+	l, sink := buildTestLogger(t, Config{
+		MinLevel:        SOVDEV_LOGLEVELS.DEBUG,
+		ConsoleMinLevel: SOVDEV_LOGLEVELS.WARN,
+		FileMinLevel:    SOVDEV_LOGLEVELS.INFO,
+	})
+	for _, level := range []SovdevLogLevel{SOVDEV_LOGLEVELS.DEBUG, SOVDEV_LOGLEVELS.INFO, SOVDEV_LOGLEVELS.WARN} {
+		if err := l.Log(level, "TestMinLevel", "Entry at "+string(level), "", nil, nil, nil, ""); err != nil {
+			t.Fatalf("Log: %v", err)
+		}
+	}
+	if err := l.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	console := stdout()
+	file, err := os.ReadFile(filepath.Join(dir, "dev.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		level                       string
+		console, file, otlp, custom bool
+	}{
+		{"debug", false, false, true, true},
+		{"info", false, true, true, true},
+		{"warn", true, true, true, true},
+	}
+	for _, tt := range tests {
+		message := "Entry at " + tt.level
+		got := map[string]bool{
+			"console":      lineContaining(console, message) != "",
+			"file":         lineContaining(string(file), message) != "",
+			"otlp":         exportedLogRecord(t, collector, message) != nil,
+			"custom sinks": false,
+		}
+		for _, entry := range sink.Entries() {
+			got["custom sinks"] = got["custom sinks"] || entry.Message == message
+		}
+		want := map[string]bool{"console": tt.console, "file": tt.file, "otlp": tt.otlp, "custom sinks": tt.custom}
+		for output, w := range want {
+			if got[output] != w {
+				t.Errorf("%s entry in %s: %v, want %v", tt.level, output, got[output], w)
+			}
+		}
+	}
+}
+
+func TestSinkMinSeverity(t *testing.T) {
+	t.Setenv("SOVDEV_CONSOLE_MIN_LEVEL", "WARN")
+	if got, want := sinkMinSeverity("", "SOVDEV_CONSOLE_MIN_LEVEL", "console"), mapToSeverityNumber(SOVDEV_LOGLEVELS.WARN); got != want {
+		t.Errorf("from env = %d, want %d", got, want)
+	}
+	if got, want := sinkMinSeverity(SOVDEV_LOGLEVELS.ERROR, "SOVDEV_CONSOLE_MIN_LEVEL", "console"), mapToSeverityNumber(SOVDEV_LOGLEVELS.ERROR); got != want {
+		t.Errorf("config over env = %d, want %d", got, want)
+	}
+	if got := sinkMinSeverity("", "SOVDEV_FILE_MIN_LEVEL", "file"); got != 0 {
+		t.Errorf("unset = %d, want 0", got)
+	}
+	stdout := captureFile(t, &os.Stdout)
+	if got := sinkMinSeverity("loud", "SOVDEV_FILE_MIN_LEVEL", "file"); got != 0 {
+		t.Errorf("invalid = %d, want 0", got)
+	}
+	if !strings.Contains(stdout(), `Invalid file min level "loud"`) {
+		t.Error("invalid min level not reported")
+	}
+}
+
+// rotatingFile returns the lumberjack logger behind the named file sink
+func rotatingFile(t *testing.T, l *Logger, name string) *lumberjack.Logger {
+	t.Helper()
+	for _, s := range l.sinks {
+		if ls, ok := s.sink.(*lineSink); ok && s.name == name {
+			switch w := ls.out.Writer().(type) {
+			case *lumberjack.Logger:
+				return w
+			case syncingFile:
+				return w.Logger
+			}
+		}
+	}
+	t.Fatalf("no %s sink", name)
+	return nil
+}
+
+func TestErrorLogRotationSettings(t *testing.T) {
+	type rotation struct {
+		maxSize, maxBackups, maxAge int
+		compress                    bool
+	}
+	tests := []struct {
+		name string
+		cfg  Config
+		env  map[string]string
+		want rotation
+	}{
+		{"defaults", Config{}, nil, rotation{10, 3, 0, false}},
+		{
+			"config",
+			Config{ErrorLogMaxSizeMB: 5, ErrorLogMaxBackups: 30, ErrorLogMaxAgeDays: 90, ErrorLogCompress: true},
+			nil,
+			rotation{5, 30, 90, true},
+		},
+		{
+			"env",
+			Config{},
+			map[string]string{"SOVDEV_ERROR_LOG_MAX_SIZE_MB": "20", "SOVDEV_ERROR_LOG_MAX_BACKUPS": "4", "SOVDEV_ERROR_LOG_MAX_AGE_DAYS": "7", "SOVDEV_ERROR_LOG_COMPRESS": "true"},
+			rotation{20, 4, 7, true},
+		},
+		{
+			"config wins over env",
+			Config{ErrorLogMaxBackups: 30},
+			map[string]string{"SOVDEV_ERROR_LOG_MAX_BACKUPS": "4"},
+			rotation{10, 30, 0, false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"SOVDEV_ERROR_LOG_MAX_SIZE_MB", "SOVDEV_ERROR_LOG_MAX_BACKUPS", "SOVDEV_ERROR_LOG_MAX_AGE_DAYS", "SOVDEV_ERROR_LOG_COMPRESS"} {
+				t.Setenv(key, tt.env[key])
+			}
+			l, _ := newFileTestLogger(t, tt.cfg)
+
+			errorLog := rotatingFile(t, l, "error_file")
+			if got := (rotation{errorLog.MaxSize, errorLog.MaxBackups, errorLog.MaxAge, errorLog.Compress}); got != tt.want {
+				t.Errorf("error.log rotation = %+v, want %+v", got, tt.want)
+			}
+			// dev.log keeps its own rotation whatever error.log is set to
+			devLog := rotatingFile(t, l, "file")
+			if got, want := (rotation{devLog.MaxSize, devLog.MaxBackups, devLog.MaxAge, devLog.Compress}), (rotation{50, 5, 0, false}); got != want {
+				t.Errorf("dev.log rotation = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestRotatedErrorLogIsCompressed(t *testing.T) {
+	l, dir := newFileTestLogger(t, Config{ErrorLogCompress: true})
+
+	if err := l.Log(SOVDEV_LOGLEVELS.ERROR, "TestRotation", "Before rotation", "", nil, nil, errors.New("boom"), ""); err != nil {
+		t.Fatalf("Log: %v", err)
+	}
+	if err := rotatingFile(t, l, "error_file").Rotate(); err != nil {
+		t.Fatalf("rotate error.log: %v", err)
+	}
+	if err := rotatingFile(t, l, "file").Rotate(); err != nil {
+		t.Fatalf("rotate dev.log: %v", err)
+	}
+
+	// lumberjack compresses backups in the background
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		errorBackups, _ := filepath.Glob(filepath.Join(dir, "error-*.log.gz"))
+		if len(errorBackups) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			entries, _ := os.ReadDir(dir)
+			t.Fatalf("no compressed error.log backup in %v", entries)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if devBackups, _ := filepath.Glob(filepath.Join(dir, "dev-*.log")); len(devBackups) != 1 {
+		t.Errorf("dev.log backups = %v, want one uncompressed", devBackups)
+	}
+}
@@ -0,0 +1,162 @@
+package sovdevlogger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SovdevStartJobSpan starts a span covering a whole batch job and returns a ctx
+// carrying it; end the span when the job finishes. Items started with
+// SovdevStartJobItemSpan under this ctx link back to it, and job progress logged
+// with SovdevLogJobProgressContext belongs to its trace, so trace UIs can show
+// the batch as one unit. Returns a non-recording span before initialization.
+func SovdevStartJobSpan(ctx context.Context, jobName string) (context.Context, trace.Span) {
+	logger := defaultLogger()
+	ctx = context.WithValue(ctx, jobNameKey, jobName)
+	if logger == nil || logger.tracer == nil {
+		return ctx, trace.SpanFromContext(ctx)
+	}
+	return logger.tracer.Start(ctx, "job "+jobName,
+		trace.WithAttributes(attribute.String("job_name", jobName)))
+}
+
+// SovdevStartJobItemSpan starts the span for one item of the job in ctx (see
+// SovdevStartJobSpan). Each item gets its own trace, so a slow or failing item
+// stays readable on its own, with a span link to the job span. Entries logged
+// with the returned ctx carry the item's trace_id and span_id.
+func SovdevStartJobItemSpan(ctx context.Context, itemID string) (context.Context, trace.Span) {
+	logger := defaultLogger()
+	if logger == nil || logger.tracer == nil {
+		return ctx, trace.SpanFromContext(ctx)
+	}
+
+	options := []trace.SpanStartOption{
+		trace.WithNewRoot(),
+		trace.WithAttributes(attribute.String("item_id", itemID)),
+	}
+	if job := trace.SpanContextFromContext(ctx); job.IsValid() {
+		options = append(options, trace.WithLinks(trace.Link{
+			SpanContext: job,
+			Attributes:  []attribute.KeyValue{attribute.String("link.type", "job")},
+		}))
+	}
+	name := "job item"
+	if jobName := jobNameFromContext(ctx); jobName != "" {
+		name = "job " + jobName + " item"
+		options = append(options, trace.WithAttributes(attribute.String("job_name", jobName)))
+	}
+	return logger.tracer.Start(ctx, name, options...)
+}
+
+// SovdevAddSpanEvent records a named event (e.g. "cache_miss", "retry") on the span
+// in ctx, as a lightweight trace-only breadcrumb instead of a full log entry.
+// attrs are sanitized like log payloads. No-op when ctx has no recording span.
+func SovdevAddSpanEvent(ctx context.Context, name string, attrs map[string]interface{}) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+
+	var options []trace.EventOption
+	if len(attrs) > 0 {
+		scrubbed, _ := scrubPayload(attrs).(map[string]interface{})
+		options = append(options, trace.WithAttributes(spanAttributes(scrubbed)...))
+	}
+	span.AddEvent(scrubString(name), options...)
+}
+
+// SovdevLogSpanError marks the span in ctx as failed and logs err at ERROR in
+// one call, so a logged failure never leaves the span green:
+//
+//	ctx, span := tracer.Start(ctx, "lookup")
+//	defer span.End()
+//	if err := fetch(ctx); err != nil {
+//	    sovdevlogger.SovdevLogSpanError(ctx, "lookupCompany", "Lookup failed", err)
+//	    return err
+//	}
+//
+// The span gets an exception event and an Error status with message; the entry
+// carries the span's trace_id and span_id, and the peer service from
+// SovdevWithPeerService. Without a recording span in ctx only the entry is logged.
+func SovdevLogSpanError(ctx context.Context, functionName, message string, err error) {
+	logger := defaultLogger()
+	if logger == nil {
+		markSpanError(ctx, message, err)
+		SovdevLogContext(ctx, SOVDEV_LOGLEVELS.ERROR, functionName, message, "", nil, nil, err, "")
+		return
+	}
+
+	if logErr := logger.LogSpanError(ctx, functionName, message, err); logErr != nil {
+		fmt.Printf("⚠️  Log entry rejected: %v\n", logErr)
+	}
+}
+
+// LogSpanError marks the span in ctx as failed and logs err (see SovdevLogSpanError)
+func (l *Logger) LogSpanError(ctx context.Context, functionName, message string, err error) error {
+	markSpanError(ctx, message, err)
+	return l.LogContext(ctx, SOVDEV_LOGLEVELS.ERROR, functionName, message, "", nil, nil, err, "")
+}
+
+// markSpanError records err on the span in ctx like span.RecordError, but with
+// the exception message redacted as in log entries, and sets an Error status
+func markSpanError(ctx context.Context, message string, err error) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	if err != nil {
+		span.AddEvent(semconv.ExceptionEventName, trace.WithAttributes(
+			semconv.ExceptionType(fmt.Sprintf("%T", err)),
+			semconv.ExceptionMessage(scrubString(removeCredentials(err.Error()))),
+		))
+	}
+	span.SetStatus(codes.Error, scrubString(message))
+}
+
+// spanAttributes converts a map into span attributes in sorted key order;
+// objects and arrays are encoded as JSON strings
+func spanAttributes(values map[string]interface{}) []attribute.KeyValue {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]attribute.KeyValue, 0, len(keys))
+	for _, k := range keys {
+		switch v := values[k].(type) {
+		case nil:
+			continue
+		case string:
+			attrs = append(attrs, attribute.String(k, v))
+		case bool:
+			attrs = append(attrs, attribute.Bool(k, v))
+		case int:
+			attrs = append(attrs, attribute.Int(k, v))
+		case int64:
+			attrs = append(attrs, attribute.Int64(k, v))
+		case float64:
+			attrs = append(attrs, attribute.Float64(k, v))
+		case json.Number:
+			if i, err := v.Int64(); err == nil {
+				attrs = append(attrs, attribute.Int64(k, i))
+			} else if f, err := v.Float64(); err == nil {
+				attrs = append(attrs, attribute.Float64(k, f))
+			} else {
+				attrs = append(attrs, attribute.String(k, v.String()))
+			}
+		default:
+			if data, err := json.Marshal(v); err == nil {
+				attrs = append(attrs, attribute.String(k, string(data)))
+			}
+		}
+	}
+	return attrs
+}
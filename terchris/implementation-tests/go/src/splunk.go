@@ -0,0 +1,168 @@
+package sovdevlogger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+const (
+	// splunkQueueSize bounds entries waiting to be sent; newer entries are dropped when full
+	splunkQueueSize = 10000
+	// splunkBatchSize is the maximum number of events per HEC request
+	splunkBatchSize = 100
+	// splunkBatchInterval sends partial batches at least this often
+	splunkBatchInterval = 2 * time.Second
+	// splunkMaxAttempts includes the first try; only 5xx and network errors are retried
+	splunkMaxAttempts = 3
+)
+
+// ErrSinkQueueFull is returned when an entry is dropped because the sink cannot keep up
+var ErrSinkQueueFull = errors.New("sink queue full, entry dropped")
+
+// ErrSinkClosed is returned when writing to a sink after Close
+var ErrSinkClosed = errors.New("sink closed")
+
+// SplunkHECSink ships entries to a Splunk HTTP Event Collector. Entries are
+// queued (bounded) and POSTed in batches by a background goroutine; requests
+// failing with a 5xx status or a network error are retried with backoff.
+type SplunkHECSink struct {
+	endpoint string
+	token    string
+	host     string
+	client   *http.Client
+	throttle *selfLogThrottle
+	queue    *batchQueue
+}
+
+// NewSplunkHECSink creates a sink posting to endpoint (e.g.
+// "https://splunk:8088/services/collector/event") with the given HEC token
+func NewSplunkHECSink(endpoint, token string) (*SplunkHECSink, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid Splunk HEC endpoint %q", endpoint)
+	}
+	if token == "" {
+		return nil, fmt.Errorf("Splunk HEC token is required")
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	s := &SplunkHECSink{
+		endpoint: endpoint,
+		token:    token,
+		host:     host,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		throttle: newSelfLogThrottle(selfLogInterval),
+	}
+	s.queue = newBatchQueue(splunkQueueSize, splunkBatchSize, splunkBatchInterval, s.send)
+	return s, nil
+}
+
+// WriteEntry queues an entry without blocking
+func (s *SplunkHECSink) WriteEntry(level SovdevLogLevel, entry StructuredLogEntry) error {
+	return s.queue.add(entry)
+}
+
+// Flush sends all queued entries, waiting until done or ctx expires
+func (s *SplunkHECSink) Flush(ctx context.Context) error {
+	return s.queue.flush(ctx)
+}
+
+// Close sends the remaining entries and stops the sink. Later writes return ErrSinkClosed.
+func (s *SplunkHECSink) Close(ctx context.Context) error {
+	return s.queue.close(ctx)
+}
+
+// send POSTs one batch, retrying 5xx responses and network errors
+func (s *SplunkHECSink) send(batch []StructuredLogEntry) {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, entry := range batch {
+		encoder.Encode(s.event(entry))
+	}
+	payload := body.Bytes()
+
+	var err error
+	for attempt := 0; attempt < splunkMaxAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * 500 * time.Millisecond)
+		}
+		var retry bool
+		retry, err = s.post(payload)
+		if err == nil || !retry {
+			break
+		}
+	}
+
+	if err != nil {
+		if ok, suppressed := s.throttle.allow("send"); ok {
+			fmt.Printf("⚠️  Splunk HEC: dropped %d entries: %v (%d similar failures suppressed)\n", len(batch), err, suppressed)
+		}
+	}
+}
+
+// post sends payload once and reports whether a failure is worth retrying
+func (s *SplunkHECSink) post(payload []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, s.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", "Splunk "+s.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode >= 500:
+		return true, fmt.Errorf("HEC returned %s", resp.Status)
+	case resp.StatusCode >= 300:
+		return false, fmt.Errorf("HEC returned %s", resp.Status)
+	}
+	return false, nil
+}
+
+// event wraps an entry in the HEC event envelope; trace_id, span_id and other
+// key fields are repeated as indexed fields for fast searching
+func (s *SplunkHECSink) event(entry StructuredLogEntry) map[string]interface{} {
+	event := map[string]interface{}{
+		"host":       s.host,
+		"source":     entry.ServiceName,
+		"sourcetype": "_json",
+		"event":      entry,
+	}
+	if ts, err := time.Parse(time.RFC3339Nano, entry.Timestamp); err == nil {
+		event["time"] = float64(ts.UnixMilli()) / 1000
+	}
+
+	fields := map[string]string{
+		"level":        entry.Level,
+		"service_name": entry.ServiceName,
+		"log_type":     string(entry.LogType),
+		"tenant_id":    entry.TenantID,
+		"trace_id":     entry.TraceID,
+		"span_id":      entry.SpanID,
+	}
+	for name, value := range fields {
+		if value == "" {
+			delete(fields, name)
+		}
+	}
+	event["fields"] = fields
+	return event
+}
@@ -0,0 +1,96 @@
+package sovdevlogger
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Output and signal names used as keys in Stats
+var (
+	statsSinks   = []string{"file", "error_file", "audit", "console", "otlp", "gelf", "splunk", "elasticsearch", "loki", "custom"}
+	statsSignals = []string{"traces", "logs", "metrics"}
+)
+
+// Stats is a snapshot of the logger's output pipeline, cheap enough to serve
+// from a readiness probe
+type Stats struct {
+	// EntriesWritten counts entries handed to each output (file, error_file, audit,
+	// console, otlp, gelf, splunk, elasticsearch, loki, and custom for all Config.Sinks together);
+	// queued outputs count accepted entries
+	EntriesWritten map[string]int64
+	// WriteFailures counts entries an output rejected (e.g. a full Splunk queue)
+	WriteFailures map[string]int64
+	// LastExport is the time of the last successful OTLP export per signal
+	// (traces, logs, metrics); zero until the first one succeeds
+	LastExport map[string]time.Time
+	// ExportFailures counts failed OTLP exports per signal
+	ExportFailures map[string]int64
+}
+
+// pipelineStats holds the counters behind Stats. The maps are filled once at
+// construction and only their atomic values change, so no lock is needed.
+type pipelineStats struct {
+	written        map[string]*atomic.Int64
+	writeFailures  map[string]*atomic.Int64
+	lastExport     map[string]*atomic.Int64 // Unix nanoseconds
+	exportFailures map[string]*atomic.Int64
+}
+
+func newPipelineStats() *pipelineStats {
+	counters := func(names []string) map[string]*atomic.Int64 {
+		m := make(map[string]*atomic.Int64, len(names))
+		for _, name := range names {
+			m[name] = new(atomic.Int64)
+		}
+		return m
+	}
+	return &pipelineStats{
+		written:        counters(statsSinks),
+		writeFailures:  counters(statsSinks),
+		lastExport:     counters(statsSignals),
+		exportFailures: counters(statsSignals),
+	}
+}
+
+func (s *pipelineStats) wrote(sink string)          { s.written[sink].Add(1) }
+func (s *pipelineStats) writeFailed(sink string)    { s.writeFailures[sink].Add(1) }
+func (s *pipelineStats) exported(signal string)     { s.lastExport[signal].Store(time.Now().UnixNano()) }
+func (s *pipelineStats) exportFailed(signal string) { s.exportFailures[signal].Add(1) }
+
+func (s *pipelineStats) snapshot() Stats {
+	load := func(m map[string]*atomic.Int64) map[string]int64 {
+		out := make(map[string]int64, len(m))
+		for name, v := range m {
+			out[name] = v.Load()
+		}
+		return out
+	}
+	lastExport := make(map[string]time.Time, len(s.lastExport))
+	for signal, v := range s.lastExport {
+		lastExport[signal] = time.Time{}
+		if ns := v.Load(); ns != 0 {
+			lastExport[signal] = time.Unix(0, ns)
+		}
+	}
+	return Stats{
+		EntriesWritten: load(s.written),
+		WriteFailures:  load(s.writeFailures),
+		LastExport:     lastExport,
+		ExportFailures: load(s.exportFailures),
+	}
+}
+
+// SovdevStats returns a snapshot of the default logger's pipeline counters, or
+// an empty Stats before SovdevInitialize. Safe to call from any goroutine.
+func SovdevStats() Stats {
+	logger := defaultLogger()
+	if logger == nil {
+		return Stats{}
+	}
+	return logger.Stats()
+}
+
+// Stats returns a snapshot of this logger's pipeline counters
+func (l *Logger) Stats() Stats {
+	return l.stats.snapshot()
+}
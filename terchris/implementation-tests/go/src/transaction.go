@@ -0,0 +1,48 @@
+package sovdevlogger
+
+import (
+	"context"
+	"fmt"
+)
+
+// SovdevTransaction runs fn as one logged transaction with a peer service, writing
+// the start entry and one of the two outcome entries of the standard pattern,
+// all with one trace_id:
+//
+//  1. INFO "<functionName> started" with input
+//  2. INFO "<functionName> completed" with input and fn's response, or
+//     ERROR "<functionName> failed" with input and fn's error as the exception
+//
+// The trace_id comes from the active span in ctx, or is generated once for all
+// entries. fn's response and error are returned unchanged:
+//
+//	company, err := sovdevlogger.SovdevTransaction(ctx, "lookupCompany", PEER_SERVICES.Mappings["BRREG"],
+//	    map[string]interface{}{"organisasjonsnummer": orgNumber},
+//	    func() (interface{}, error) { return fetchCompanyData(orgNumber) })
+func SovdevTransaction(ctx context.Context, functionName, peerService string, input interface{}, fn func() (response interface{}, err error)) (interface{}, error) {
+	return runTransaction(functionName, fn, func(level SovdevLogLevel, message string, response interface{}, err error, traceID string) {
+		SovdevLogContext(ctx, level, functionName, message, peerService, input, response, err, traceID)
+	})
+}
+
+// Transaction runs fn as one logged transaction (see SovdevTransaction). Entries
+// rejected by StrictValidation are skipped; fn still runs.
+func (l *Logger) Transaction(ctx context.Context, functionName, peerService string, input interface{}, fn func() (response interface{}, err error)) (interface{}, error) {
+	return runTransaction(functionName, fn, func(level SovdevLogLevel, message string, response interface{}, err error, traceID string) {
+		l.LogContext(ctx, level, functionName, message, peerService, input, response, err, traceID)
+	})
+}
+
+// runTransaction logs the start, runs fn and logs its outcome through log
+func runTransaction(functionName string, fn func() (interface{}, error), log func(level SovdevLogLevel, message string, response interface{}, err error, traceID string)) (interface{}, error) {
+	traceID := SovdevGenerateTraceID()
+
+	log(SOVDEV_LOGLEVELS.INFO, fmt.Sprintf("%s started", functionName), nil, nil, traceID)
+	response, err := fn()
+	if err != nil {
+		log(SOVDEV_LOGLEVELS.ERROR, fmt.Sprintf("%s failed", functionName), nil, err, traceID)
+		return response, err
+	}
+	log(SOVDEV_LOGLEVELS.INFO, fmt.Sprintf("%s completed", functionName), response, nil, traceID)
+	return response, nil
+}
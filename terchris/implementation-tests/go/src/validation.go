@@ -0,0 +1,84 @@
+package sovdevlogger
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Validation errors returned by Logger.Log/LogContext when Config.StrictValidation is set
+var (
+	ErrMissingFunctionName = errors.New("function_name is required")
+	ErrInvalidLevel        = errors.New("invalid log level")
+	ErrInvalidLogType      = errors.New("invalid log type")
+)
+
+// validateEntry checks the fields "Loggeloven av 2025" requires before an entry is emitted.
+// An empty level means defaultLevel. In lenient mode it repairs the values (empty function
+// name becomes "unknown", an invalid level becomes defaultLevel) and returns a warning to
+// attach to the entry; a log type outside the standard ones and extraLogTypes is kept but
+// warned about. In strict mode it returns an error instead and the entry must not be emitted.
+func validateEntry(strict bool, level, defaultLevel SovdevLogLevel, functionName string, logType SovdevLogType, extraLogTypes []SovdevLogType) (SovdevLogLevel, string, string, error) {
+	var warnings []string
+
+	if level == "" {
+		level = defaultLevel
+	}
+
+	if functionName == "" {
+		if strict {
+			return level, functionName, "", ErrMissingFunctionName
+		}
+		functionName = "unknown"
+		warnings = append(warnings, "empty function_name")
+	}
+
+	if !isValidLevel(level) {
+		if strict {
+			return level, functionName, "", fmt.Errorf("%w: %q", ErrInvalidLevel, level)
+		}
+		warnings = append(warnings, fmt.Sprintf("invalid level %q defaulted to %s", level, defaultLevel))
+		level = defaultLevel
+	}
+
+	if !isKnownLogType(logType, extraLogTypes) {
+		if strict {
+			return level, functionName, "", fmt.Errorf("%w: %q is not allowed", ErrInvalidLogType, logType)
+		}
+		warnings = append(warnings, fmt.Sprintf("unknown log_type %q", logType))
+	}
+
+	warning := ""
+	for i, w := range warnings {
+		if i > 0 {
+			warning += "; "
+		}
+		warning += w
+	}
+	return level, functionName, warning, nil
+}
+
+// sessionIDPattern is the session_id format required by the log entry schema
+var sessionIDPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+// isValidSessionID reports whether id is usable as session_id (a lowercase UUID v4)
+func isValidSessionID(id string) bool {
+	return sessionIDPattern.MatchString(id)
+}
+
+// normalizeTraceID converts a caller-supplied trace ID to the W3C format used
+// by trace_id: surrounding space and UUID dashes are removed and hex is
+// lowercased, so SovdevGenerateTraceID output, uuid.New().String() (github.com/google/uuid) and
+// uppercase hex are all accepted. Reports false for anything else, including
+// the all-zero ID that W3C defines as invalid.
+func normalizeTraceID(id string) (string, bool) {
+	id = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(id), "-", ""))
+	traceID, err := trace.TraceIDFromHex(id)
+	if err != nil {
+		return "", false
+	}
+	return traceID.String(), true
+}
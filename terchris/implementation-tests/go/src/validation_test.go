@@ -0,0 +1,163 @@
+package sovdevlogger
+
+import (
+	"errors"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestLenientValidationRepairsEntry(t *testing.T) {
+	l, sink := newTestLogger(t, Config{})
+
+	if err := l.Log(SOVDEV_LOGLEVELS.INFO, "", "No function name", "", nil, nil, nil, ""); err != nil {
+		t.Fatalf("Log with empty function name: %v", err)
+	}
+	if err := l.Log("verbose", "TestValidation", "Bogus level", "", nil, nil, nil, ""); err != nil {
+		t.Fatalf("Log with bogus level: %v", err)
+	}
+
+	entries := sink.Entries()
+	if len(entries) < 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	missing, bogus := entries[len(entries)-2], entries[len(entries)-1]
+
+	if missing.FunctionName != "unknown" || !strings.Contains(missing.ValidationWarning, "empty function_name") {
+		t.Errorf("empty function name: function_name=%q validation_warning=%q", missing.FunctionName, missing.ValidationWarning)
+	}
+	if bogus.Level != string(SOVDEV_LOGLEVELS.INFO) || !strings.Contains(bogus.ValidationWarning, `invalid level "verbose"`) {
+		t.Errorf("bogus level: level=%q validation_warning=%q", bogus.Level, bogus.ValidationWarning)
+	}
+}
+
+func TestStrictValidationRejectsEntry(t *testing.T) {
+	l, sink := newTestLogger(t, Config{StrictValidation: true})
+	before := len(sink.Entries())
+
+	err := l.Log(SOVDEV_LOGLEVELS.INFO, "", "No function name", "", nil, nil, nil, "")
+	if !errors.Is(err, ErrMissingFunctionName) {
+		t.Errorf("empty function name: err = %v, want ErrMissingFunctionName", err)
+	}
+	err = l.Log("verbose", "TestValidation", "Bogus level", "", nil, nil, nil, "")
+	if !errors.Is(err, ErrInvalidLevel) {
+		t.Errorf("bogus level: err = %v, want ErrInvalidLevel", err)
+	}
+	if n := len(sink.Entries()) - before; n != 0 {
+		t.Errorf("%d rejected entries were written", n)
+	}
+}
+
+func TestEmptyLevelUsesDefaultLevel(t *testing.T) {
+	l, sink := newTestLogger(t, Config{StrictValidation: true})
+	l.SetDefaultLevel(SOVDEV_LOGLEVELS.WARN)
+
+	if err := l.Log("", "TestValidation", "No level", "", nil, nil, nil, ""); err != nil {
+		t.Fatalf("Log with empty level: %v", err)
+	}
+	entry := sink.Entries()[len(sink.Entries())-1]
+	if entry.Level != string(SOVDEV_LOGLEVELS.WARN) || entry.ValidationWarning != "" {
+		t.Errorf("level=%q validation_warning=%q, want warn without a warning", entry.Level, entry.ValidationWarning)
+	}
+}
+
+func TestDefaultLevelChangesAtRuntime(t *testing.T) {
+	sink := &recordingSink{}
+	cfg := testConfig("1.0.0")
+	cfg.Sinks = []Sink{sink}
+	initTestDefault(t, cfg)
+
+	logWith := func(level SovdevLogLevel) StructuredLogEntry {
+		t.Helper()
+		SovdevLog(level, "TestDefaultLevel", "Level "+string(level), "", nil, nil, nil, "")
+		entries := sink.Entries()
+		if len(entries) == 0 {
+			t.Fatal("no entry written")
+		}
+		return entries[len(entries)-1]
+	}
+
+	if got := logWith("").Level; got != string(SOVDEV_LOGLEVELS.INFO) {
+		t.Errorf("empty level logged as %q before SovdevSetDefaultLevel, want info", got)
+	}
+
+	SovdevSetDefaultLevel(SOVDEV_LOGLEVELS.ERROR)
+	if got := logWith("").Level; got != string(SOVDEV_LOGLEVELS.ERROR) {
+		t.Errorf("empty level logged as %q, want the new default error", got)
+	}
+	if entry := logWith("verbose"); entry.Level != string(SOVDEV_LOGLEVELS.ERROR) || entry.ValidationWarning == "" {
+		t.Errorf("invalid level: level=%q validation_warning=%q, want error with a warning", entry.Level, entry.ValidationWarning)
+	}
+
+	SovdevSetDefaultLevel("bogus")
+	if got := logWith("").Level; got != string(SOVDEV_LOGLEVELS.ERROR) {
+		t.Errorf("invalid default level was accepted: empty level logged as %q", got)
+	}
+}
+
+func TestInvalidLevelWarnsOnce(t *testing.T) {
+	l, _ := newTestLogger(t, Config{})
+	stdout := captureFile(t, &os.Stdout)
+
+	for _, level := range []SovdevLogLevel{"verbose", "loud", "verbose"} {
+		if err := l.Log(level, "TestValidation", "Bogus level", "", nil, nil, nil, ""); err != nil {
+			t.Fatalf("Log: %v", err)
+		}
+	}
+	if n := strings.Count(stdout(), "Invalid log level"); n != 1 {
+		t.Errorf("invalid level warning printed %d times, want once", n)
+	}
+}
+
+func TestNormalizeTraceID(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   string
+		wantOK bool
+	}{
+		{"4bf92f3577b34da6a3ce929d0e0e4736", "4bf92f3577b34da6a3ce929d0e0e4736", true},
+		{"4BF92F3577B34DA6A3CE929D0E0E4736", "4bf92f3577b34da6a3ce929d0e0e4736", true},
+		{"4bf92f35-77b3-4da6-a3ce-929d0e0e4736", "4bf92f3577b34da6a3ce929d0e0e4736", true},
+		{" 4bf92f3577b34da6a3ce929d0e0e4736 ", "4bf92f3577b34da6a3ce929d0e0e4736", true},
+		{"00000000000000000000000000000000", "", false},
+		{"4bf92f3577b34da6", "", false},
+		{"not-a-trace-id", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := normalizeTraceID(tt.in)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("normalizeTraceID(%q) = %q, %v; want %q, %v", tt.in, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestLoggedTraceIDs(t *testing.T) {
+	l, sink := newTestLogger(t, Config{})
+	hex32 := regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+	tests := []struct {
+		name        string
+		traceID     string
+		want        string // "" accepts any generated ID
+		wantWarning bool
+	}{
+		{"empty", "", "", false},
+		{"uuid with dashes", "4bf92f35-77b3-4da6-a3ce-929d0e0e4736", "4bf92f3577b34da6a3ce929d0e0e4736", false},
+		{"garbage", "request-42", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := l.Log(SOVDEV_LOGLEVELS.INFO, "TestTraceID", "Trace ID", "", nil, nil, nil, tt.traceID); err != nil {
+				t.Fatalf("Log: %v", err)
+			}
+			entry := sink.Entries()[len(sink.Entries())-1]
+			if !hex32.MatchString(entry.TraceID) || (tt.want != "" && entry.TraceID != tt.want) {
+				t.Errorf("trace_id = %q, want %q", entry.TraceID, tt.want)
+			}
+			if hasWarning := strings.Contains(entry.ValidationWarning, "invalid trace_id"); hasWarning != tt.wantWarning {
+				t.Errorf("validation_warning = %q, want a trace_id warning: %v", entry.ValidationWarning, tt.wantWarning)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -8,6 +9,8 @@ import (
 	"os"
 	"time"
 
+	"go.opentelemetry.io/otel"
+
 	sovdevlogger "github.com/redcross-public/sovdev-logger/go/src"
 )
 
@@ -36,13 +39,14 @@ func fetchCompanyData(orgNumber string) (*CompanyData, error) {
 
 	resp, err := http.Get(url)
 	if err != nil {
-		return nil, err
+		return nil, sovdevlogger.SovdevErrorWithCode("ERR_BRREG_UNAVAILABLE", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+		// Stable code for alerting; the message varies with the response body
+		return nil, sovdevlogger.SovdevErrorWithCode("ERR_BRREG_HTTP", fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body)))
 	}
 
 	var data CompanyData
@@ -62,8 +66,13 @@ func lookupCompany(orgNumber string) error {
 		"organisasjonsnummer": orgNumber,
 	}
 
-	// Generate trace ID for correlation
-	traceID := sovdevlogger.SovdevGenerateTraceID()
+	// One span per lookup; its trace ID correlates the transaction's entries
+	ctx, span := otel.Tracer("company-lookup").Start(context.Background(), FUNCTIONNAME)
+	defer span.End()
+	traceID := sovdevlogger.SovdevTraceIDFromContext(ctx)
+	if traceID == "" {
+		traceID = sovdevlogger.SovdevGenerateTraceID()
+	}
 
 	// LOG #1: Transaction Start
 	sovdevlogger.SovdevLog(
@@ -80,16 +89,12 @@ func lookupCompany(orgNumber string) error {
 	// Fetch company data
 	companyData, err := fetchCompanyData(orgNumber)
 	if err != nil {
-		// LOG #2: Transaction Error
-		sovdevlogger.SovdevLog(
-			sovdevlogger.SOVDEV_LOGLEVELS.ERROR,
+		// LOG #2: Transaction Error - also marks the lookup span as failed
+		sovdevlogger.SovdevLogSpanError(
+			sovdevlogger.SovdevWithPeerService(ctx, PEER_SERVICES.Mappings["BRREG"]),
 			FUNCTIONNAME,
 			fmt.Sprintf("Failed to lookup company %s", orgNumber),
-			PEER_SERVICES.Mappings["BRREG"],
-			input,
-			nil,
 			err,
-			traceID,
 		)
 		return err
 	}